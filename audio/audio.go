@@ -0,0 +1,48 @@
+// Package audio holds mono sample buffers and reads and writes them as WAV
+// files and raw PCM.
+package audio
+
+// Clip is a mono sound with samples in the range -1 to 1.
+type Clip struct {
+	Rate    int
+	Samples []float32
+}
+
+// Resample returns the clip converted to rate by linear interpolation.
+func (c *Clip) Resample(rate int) *Clip {
+	if c.Rate == rate || len(c.Samples) == 0 {
+		return &Clip{Rate: rate, Samples: c.Samples}
+	}
+	n := len(c.Samples) * rate / c.Rate
+	out := make([]float32, n)
+	step := float64(c.Rate) / float64(rate)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		frac := float32(pos - float64(j))
+		a := c.Samples[j]
+		b := a
+		if j+1 < len(c.Samples) {
+			b = c.Samples[j+1]
+		}
+		out[i] = a + (b-a)*frac
+	}
+	return &Clip{Rate: rate, Samples: out}
+}
+
+// Writer consumes a stream of mono samples.
+type Writer interface {
+	Write(samples []float32) error
+	// Close flushes the stream. It does not close the underlying file.
+	Close() error
+}
+
+// toInt16 converts a sample to 16-bit PCM, clipping it to the valid range.
+func toInt16(s float32) int16 {
+	if s > 1 {
+		s = 1
+	} else if s < -1 {
+		s = -1
+	}
+	return int16(s * 32767)
+}
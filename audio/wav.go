@@ -0,0 +1,221 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	wavFormatPCM   = 1
+	wavFormatFloat = 3
+)
+
+// ReadWAV decodes a WAV file holding 8, 16 or 24-bit PCM or 32-bit float
+// samples. Multi-channel files are mixed down to mono.
+func ReadWAV(r io.Reader) (*Clip, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("audio: reading WAV header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("audio: not a WAV file")
+	}
+
+	var format, channels, bits uint16
+	var rate uint32
+	haveFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("audio: WAV file has no data chunk: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("audio: WAV format chunk is too short")
+			}
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("audio: reading WAV format: %w", err)
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			channels = binary.LittleEndian.Uint16(body[2:4])
+			rate = binary.LittleEndian.Uint32(body[4:8])
+			bits = binary.LittleEndian.Uint16(body[14:16])
+			if format == 0xfffe && size >= 26 {
+				// WAVE_FORMAT_EXTENSIBLE keeps the real format in the GUID.
+				format = binary.LittleEndian.Uint16(body[24:26])
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, errors.New("audio: WAV data chunk before format chunk")
+			}
+			// A streamed file gives its data size as 0xffffffff and
+			// ends early, so read what there is rather than trusting
+			// the size.
+			data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+			if err != nil {
+				return nil, fmt.Errorf("audio: reading WAV data: %w", err)
+			}
+			return decodeWAVData(data, format, channels, bits, int(rate))
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return nil, fmt.Errorf("audio: skipping WAV chunk %q: %w", id, err)
+			}
+		}
+	}
+}
+
+func decodeWAVData(data []byte, format, channels, bits uint16, rate int) (*Clip, error) {
+	if channels == 0 || rate == 0 {
+		return nil, errors.New("audio: WAV file has no channels")
+	}
+	var decode func([]byte) float32
+	switch {
+	case format == wavFormatPCM && bits == 8:
+		decode = func(b []byte) float32 { return (float32(b[0]) - 128) / 128 }
+	case format == wavFormatPCM && bits == 16:
+		decode = func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == wavFormatPCM && bits == 24:
+		decode = func(b []byte) float32 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			return float32(v) / (1 << 23)
+		}
+	case format == wavFormatFloat && bits == 32:
+		decode = func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }
+	default:
+		return nil, fmt.Errorf("audio: unsupported WAV encoding (format %d, %d bits)", format, bits)
+	}
+
+	width := int(bits / 8)
+	frame := width * int(channels)
+	clip := &Clip{Rate: rate, Samples: make([]float32, len(data)/frame)}
+	for i := range clip.Samples {
+		var sum float32
+		for c := 0; c < int(channels); c++ {
+			offset := i*frame + c*width
+			sum += decode(data[offset : offset+width])
+		}
+		clip.Samples[i] = sum / float32(channels)
+	}
+	return clip, nil
+}
+
+// WAVWriter writes 16-bit mono PCM as a WAV file. When the underlying writer
+// is an io.WriteSeeker, Close fills in the chunk sizes; otherwise they are
+// left as 0xffffffff, which most readers take to mean "until end of file".
+type WAVWriter struct {
+	w       io.Writer
+	buf     *bufio.Writer
+	rate    int
+	samples uint32
+	header  bool
+}
+
+// NewWAVWriter returns a writer producing a WAV stream at rate samples per
+// second.
+func NewWAVWriter(w io.Writer, rate int) *WAVWriter {
+	return &WAVWriter{w: w, buf: bufio.NewWriter(w), rate: rate}
+}
+
+func (w *WAVWriter) writeHeader(dataSize uint32) error {
+	riffSize := dataSize
+	if dataSize != 0xffffffff {
+		riffSize = 36 + dataSize
+	}
+	var h [44]byte
+	copy(h[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:8], riffSize)
+	copy(h[8:12], "WAVE")
+	copy(h[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:20], 16)
+	binary.LittleEndian.PutUint16(h[20:22], wavFormatPCM)
+	binary.LittleEndian.PutUint16(h[22:24], 1)
+	binary.LittleEndian.PutUint32(h[24:28], uint32(w.rate))
+	binary.LittleEndian.PutUint32(h[28:32], uint32(w.rate*2))
+	binary.LittleEndian.PutUint16(h[32:34], 2)
+	binary.LittleEndian.PutUint16(h[34:36], 16)
+	copy(h[36:40], "data")
+	binary.LittleEndian.PutUint32(h[40:44], dataSize)
+	_, err := w.buf.Write(h[:])
+	return err
+}
+
+func (w *WAVWriter) Write(samples []float32) error {
+	if !w.header {
+		if err := w.writeHeader(0xffffffff); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	var b [2]byte
+	for _, s := range samples {
+		binary.LittleEndian.PutUint16(b[:], uint16(toInt16(s)))
+		if _, err := w.buf.Write(b[:]); err != nil {
+			return err
+		}
+	}
+	w.samples += uint32(len(samples))
+	return nil
+}
+
+func (w *WAVWriter) Close() error {
+	if !w.header {
+		if err := w.Write(nil); err != nil {
+			return err
+		}
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	seeker, ok := w.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		// Not really seekable, such as a pipe; keep the streaming sizes.
+		return nil
+	}
+	w.buf.Reset(w.w)
+	if err := w.writeHeader(w.samples * 2); err != nil {
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	_, err := seeker.Seek(0, io.SeekEnd)
+	return err
+}
+
+// PCMWriter writes bare 16-bit little-endian mono samples, as read by
+// aplay -f S16_LE -c 1 or ffmpeg -f s16le -ac 1.
+type PCMWriter struct {
+	buf *bufio.Writer
+}
+
+// NewPCMWriter returns a writer producing raw PCM.
+func NewPCMWriter(w io.Writer) *PCMWriter {
+	return &PCMWriter{buf: bufio.NewWriter(w)}
+}
+
+func (w *PCMWriter) Write(samples []float32) error {
+	var b [2]byte
+	for _, s := range samples {
+		binary.LittleEndian.PutUint16(b[:], uint16(toInt16(s)))
+		if _, err := w.buf.Write(b[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *PCMWriter) Close() error {
+	return w.buf.Flush()
+}
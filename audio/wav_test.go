@@ -0,0 +1,220 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// wavFile builds a WAV file with the given format chunk body and sample data,
+// with an unrelated chunk of odd length between them.
+func wavFile(format, channels, bits uint16, rate uint32, data []byte) []byte {
+	fmtChunk := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtChunk[0:2], format)
+	binary.LittleEndian.PutUint16(fmtChunk[2:4], channels)
+	binary.LittleEndian.PutUint32(fmtChunk[4:8], rate)
+	binary.LittleEndian.PutUint32(fmtChunk[8:12], rate*uint32(channels*bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[12:14], channels*bits/8)
+	binary.LittleEndian.PutUint16(fmtChunk[14:16], bits)
+	if format == 0xfffe {
+		ext := make([]byte, 24)
+		binary.LittleEndian.PutUint16(ext[0:2], 22)
+		binary.LittleEndian.PutUint16(ext[8:10], wavFormatPCM)
+		fmtChunk = append(fmtChunk, ext...)
+	}
+
+	var b bytes.Buffer
+	chunk := func(id string, body []byte) {
+		b.WriteString(id)
+		binary.Write(&b, binary.LittleEndian, uint32(len(body)))
+		b.Write(body)
+		if len(body)%2 == 1 {
+			b.WriteByte(0)
+		}
+	}
+	b.WriteString("RIFF\x00\x00\x00\x00WAVE")
+	chunk("fmt ", fmtChunk)
+	chunk("LIST", []byte("odd"))
+	chunk("data", data)
+	return b.Bytes()
+}
+
+func le16(values ...int16) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, values)
+	return b.Bytes()
+}
+
+func float32s(values ...float32) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, values)
+	return b.Bytes()
+}
+
+func TestReadWAV(t *testing.T) {
+	tests := []struct {
+		name string
+		file []byte
+		want []float32
+	}{
+		{"8-bit", wavFile(wavFormatPCM, 1, 8, 8000, []byte{0x80, 0xc0, 0x00}), []float32{0, 0.5, -1}},
+		{"16-bit", wavFile(wavFormatPCM, 1, 16, 8000, le16(0, 16384, -32768)), []float32{0, 0.5, -1}},
+		{"24-bit", wavFile(wavFormatPCM, 1, 24, 8000, []byte{0, 0, 0, 0, 0, 0x40, 0, 0, 0x80}), []float32{0, 0.5, -1}},
+		{"float", wavFile(wavFormatFloat, 1, 32, 8000, float32s(0, 0.5, -1)), []float32{0, 0.5, -1}},
+		{"stereo mixed to mono", wavFile(wavFormatPCM, 2, 16, 8000, le16(16384, -8192, -32768, -32768)), []float32{0.125, -1}},
+		{"extensible", wavFile(0xfffe, 1, 16, 8000, le16(16384)), []float32{0.5}},
+		{"partial frame dropped", wavFile(wavFormatPCM, 2, 16, 8000, le16(16384, 16384, 1)), []float32{0.5}},
+	}
+	for _, tc := range tests {
+		clip, err := ReadWAV(bytes.NewReader(tc.file))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if clip.Rate != 8000 {
+			t.Errorf("%s: rate %d, want 8000", tc.name, clip.Rate)
+		}
+		if len(clip.Samples) != len(tc.want) {
+			t.Errorf("%s: samples %v, want %v", tc.name, clip.Samples, tc.want)
+			continue
+		}
+		for i, want := range tc.want {
+			if math.Abs(float64(clip.Samples[i]-want)) > 1e-6 {
+				t.Errorf("%s: samples %v, want %v", tc.name, clip.Samples, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestReadWAVErrors(t *testing.T) {
+	good := wavFile(wavFormatPCM, 1, 16, 8000, le16(0))
+	dataFirst := append([]byte("RIFF\x00\x00\x00\x00WAVE"), good[len(good)-10:]...)
+	for name, file := range map[string][]byte{
+		"empty":              nil,
+		"not RIFF":           append([]byte("RIFX"), good[4:]...),
+		"no data chunk":      good[:len(good)-10],
+		"data before format": dataFirst,
+		"12-bit":             wavFile(wavFormatPCM, 1, 12, 8000, le16(0)),
+		"64-bit float":       wavFile(wavFormatFloat, 1, 64, 8000, make([]byte, 8)),
+		"no channels":        wavFile(wavFormatPCM, 0, 16, 8000, le16(0)),
+	} {
+		if _, err := ReadWAV(bytes.NewReader(file)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+var roundTrip = []float32{0, 0.5, -0.5, 1, -1, 2, -2}
+
+// checkRoundTrip checks that clip holds roundTrip as 16-bit samples, clipped
+// to the valid range.
+func checkRoundTrip(t *testing.T, clip *Clip) {
+	t.Helper()
+	if clip.Rate != 44100 || len(clip.Samples) != len(roundTrip) {
+		t.Fatalf("read %d samples at %d Hz, want %d at 44100", len(clip.Samples), clip.Rate, len(roundTrip))
+	}
+	for i, s := range roundTrip {
+		want := float64(s)
+		if want > 1 {
+			want = 1
+		} else if want < -1 {
+			want = -1
+		}
+		if got := float64(clip.Samples[i]); math.Abs(got-want) > 1.0/16384 {
+			t.Errorf("sample %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestWAVWriterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWAVWriter(file, 44100)
+	if err := w.Write(roundTrip[:3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(roundTrip[3:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dataSize := uint32(2 * len(roundTrip))
+	// Close leaves the file positioned at its end
+	if end, err := file.Seek(0, io.SeekCurrent); err != nil || end != 44+int64(dataSize) {
+		t.Errorf("offset after Close %d, %v, want %d", end, err, 44+dataSize)
+	}
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 44+int(dataSize) {
+		t.Fatalf("file is %d bytes, want %d", len(data), 44+dataSize)
+	}
+	if riff, size := binary.LittleEndian.Uint32(data[4:8]), binary.LittleEndian.Uint32(data[40:44]); riff != 36+dataSize || size != dataSize {
+		t.Errorf("RIFF size %d, data size %d, want %d and %d", riff, size, 36+dataSize, dataSize)
+	}
+	clip, err := ReadWAV(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, clip)
+}
+
+func TestWAVWriterStream(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWAVWriter(&b, 44100)
+	if err := w.Write(roundTrip); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if riff, size := binary.LittleEndian.Uint32(data[4:8]), binary.LittleEndian.Uint32(data[40:44]); riff != 0xffffffff || size != 0xffffffff {
+		t.Errorf("streamed RIFF size %08X, data size %08X, want FFFFFFFF", riff, size)
+	}
+	clip, err := ReadWAV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, clip)
+}
+
+func TestWAVWriterEmpty(t *testing.T) {
+	var b bytes.Buffer
+	if err := NewWAVWriter(&b, 8000).Close(); err != nil {
+		t.Fatal(err)
+	}
+	clip, err := ReadWAV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clip.Samples) != 0 || clip.Rate != 8000 {
+		t.Errorf("read %d samples at %d Hz, want none at 8000", len(clip.Samples), clip.Rate)
+	}
+}
+
+func TestPCMWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewPCMWriter(&b)
+	if err := w.Write([]float32{0, 1, -1, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := le16(0, 32767, -32767, 32767); !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("PCM % x, want % x", b.Bytes(), want)
+	}
+}
@@ -0,0 +1,55 @@
+// Package capture records emulated video frames as animated GIFs or as raw
+// video streams.
+package capture
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Recorder receives video frames in display order.
+type Recorder interface {
+	// AddFrame records one frame. All frames must be the same size.
+	AddFrame(img *image.Paletted) error
+	// Close finishes the recording. It does not close the underlying writer.
+	Close() error
+}
+
+// Formats supported by New.
+const (
+	GIF = "gif"
+	Y4M = "y4m"
+	RGB = "rgb"
+)
+
+// FormatFromPath guesses the recording format from a file extension.
+func FormatFromPath(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".gif":
+		return GIF, nil
+	case ".y4m":
+		return Y4M, nil
+	case ".rgb", ".raw":
+		return RGB, nil
+	default:
+		return "", fmt.Errorf("capture: cannot tell the format of %q", path)
+	}
+}
+
+// New returns a recorder that writes format to w. frameRate is the rate at
+// which frames will be added, in frames per second.
+func New(format string, w io.Writer, frameRate int) (Recorder, error) {
+	switch format {
+	case GIF:
+		return NewGIFRecorder(w, frameRate), nil
+	case Y4M:
+		return NewY4MRecorder(w, frameRate), nil
+	case RGB:
+		return NewRGBRecorder(w), nil
+	default:
+		return nil, fmt.Errorf("capture: unknown format %q", format)
+	}
+}
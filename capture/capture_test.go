@@ -0,0 +1,133 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"testing"
+)
+
+var testPalette = color.Palette{
+	color.Black, color.White,
+	color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff},
+	color.RGBA{0, 0, 0xff, 0xff}, color.RGBA{0xff, 0xff, 0, 0xff},
+	color.RGBA{0, 0xff, 0xff, 0xff}, color.RGBA{0xff, 0, 0xff, 0xff},
+}
+
+// solid returns a 4x2 frame filled with palette index i.
+func solid(i uint8) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, 4, 2), testPalette)
+	for p := range img.Pix {
+		img.Pix[p] = i
+	}
+	return img
+}
+
+func TestGIFRecorder(t *testing.T) {
+	tests := []struct {
+		name      string
+		frameRate int
+		frames    []uint8 // palette index of each frame offered
+		kept      []uint8 // palette index of each frame in the GIF
+		delays    []int
+	}{
+		// at 60 fps frames are 1.67/100 s apart, so some fall within
+		// minGIFDelay of the last one kept and are dropped
+		{"60 fps", 60, []uint8{0, 1, 2, 3, 4, 5, 6}, []uint8{0, 2, 3, 5, 6}, []int{3, 2, 3, 2, 2}},
+		{"identical frames merge", 25, []uint8{0, 0, 0, 1, 1, 2}, []uint8{0, 1, 2}, []int{12, 8, 4}},
+		{"last frame held to the end", 25, []uint8{0, 1, 1, 1}, []uint8{0, 1}, []int{4, 12}},
+		{"single frame", 25, []uint8{3}, []uint8{3}, []int{4}},
+		{"merged after a drop", 60, []uint8{0, 1, 0, 0, 2}, []uint8{0, 2}, []int{6, 2}},
+	}
+	for _, tc := range tests {
+		var out bytes.Buffer
+		g := NewGIFRecorder(&out, tc.frameRate)
+		img := solid(0)
+		for _, index := range tc.frames {
+			// the recorder must copy the frame, as callers reuse it
+			for p := range img.Pix {
+				img.Pix[p] = index
+			}
+			if err := g.AddFrame(img); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		anim, err := gif.DecodeAll(&out)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(anim.Image) != len(tc.kept) {
+			t.Errorf("%s: %d frames, want %d", tc.name, len(anim.Image), len(tc.kept))
+			continue
+		}
+		for i, frame := range anim.Image {
+			if frame.Pix[0] != tc.kept[i] || anim.Delay[i] != tc.delays[i] {
+				t.Errorf("%s: frame %d shows %d for %d/100 s, want %d for %d/100 s",
+					tc.name, i, frame.Pix[0], anim.Delay[i], tc.kept[i], tc.delays[i])
+			}
+		}
+	}
+}
+
+func TestGIFRecorderEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewGIFRecorder(&out, 60).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("wrote %d bytes with no frames", out.Len())
+	}
+}
+
+func TestY4MRecorder(t *testing.T) {
+	var out bytes.Buffer
+	y := NewY4MRecorder(&out, 60)
+	first := solid(0)
+	first.SetColorIndex(1, 0, 1)
+	first.SetColorIndex(3, 1, 1)
+	for _, img := range []*image.Paletted{first, solid(2)} {
+		if err := y.AddFrame(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := y.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(&out)
+	header, err := r.ReadString('\n')
+	if want := "YUV4MPEG2 W4 H2 F60:1 Ip A1:1 C444\n"; err != nil || header != want {
+		t.Fatalf("header %q, %v, want %q", header, err, want)
+	}
+	red := [3]byte{}
+	red[0], red[1], red[2] = color.RGBToYCbCr(0xff, 0, 0)
+	for frame, want := range [][3][]byte{
+		// Y, Cb and Cr planes, one row after another; white is lit at
+		// (1, 0) and (3, 1) on black
+		{{0, 255, 0, 0, 0, 0, 0, 255}, bytes.Repeat([]byte{128}, 8), bytes.Repeat([]byte{128}, 8)},
+		{bytes.Repeat(red[0:1], 8), bytes.Repeat(red[1:2], 8), bytes.Repeat(red[2:3], 8)},
+	} {
+		if tag, err := r.ReadString('\n'); err != nil || tag != "FRAME\n" {
+			t.Fatalf("frame %d starts %q, %v, want FRAME", frame, tag, err)
+		}
+		for component, plane := range want {
+			got := make([]byte, 8)
+			if _, err := io.ReadFull(r, got); err != nil {
+				t.Fatalf("frame %d: %v", frame, err)
+			}
+			if !bytes.Equal(got, plane) {
+				t.Errorf("frame %d plane %d = %v, want %v", frame, component, got, plane)
+			}
+		}
+	}
+	if rest, _ := io.ReadAll(r); len(rest) != 0 {
+		t.Errorf("%d bytes after the last frame", len(rest))
+	}
+}
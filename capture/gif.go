@@ -0,0 +1,63 @@
+package capture
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"io"
+)
+
+// minGIFDelay is the shortest frame delay, in hundredths of a second, that
+// browsers and viewers honour. Shorter delays are commonly slowed to 1/10 s.
+const minGIFDelay = 2
+
+// GIFRecorder collects frames into an animated GIF, written out on Close.
+// Frames arriving faster than minGIFDelay apart are dropped, and runs of
+// identical frames are merged into one longer frame.
+type GIFRecorder struct {
+	w         io.Writer
+	frameRate int
+	anim      gif.GIF
+	frames    int // frames offered so far
+	shownAt   int // time the last kept frame was shown, in 1/100 s
+}
+
+// NewGIFRecorder returns a recorder that writes an animated GIF to w.
+func NewGIFRecorder(w io.Writer, frameRate int) *GIFRecorder {
+	return &GIFRecorder{w: w, frameRate: frameRate}
+}
+
+func (g *GIFRecorder) AddFrame(img *image.Paletted) error {
+	now := g.frames * 100 / g.frameRate
+	g.frames++
+
+	last := len(g.anim.Image) - 1
+	if last >= 0 {
+		if now-g.shownAt < minGIFDelay {
+			return nil
+		}
+		if bytes.Equal(g.anim.Image[last].Pix, img.Pix) {
+			return nil
+		}
+		g.anim.Delay[last] = now - g.shownAt
+	}
+
+	frame := image.NewPaletted(img.Rect, img.Palette)
+	copy(frame.Pix, img.Pix)
+	g.anim.Image = append(g.anim.Image, frame)
+	g.anim.Delay = append(g.anim.Delay, minGIFDelay)
+	g.shownAt = now
+	return nil
+}
+
+func (g *GIFRecorder) Close() error {
+	if len(g.anim.Image) == 0 {
+		return nil
+	}
+	last := len(g.anim.Image) - 1
+	end := g.frames * 100 / g.frameRate
+	if end-g.shownAt > minGIFDelay {
+		g.anim.Delay[last] = end - g.shownAt
+	}
+	return gif.EncodeAll(g.w, &g.anim)
+}
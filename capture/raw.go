@@ -0,0 +1,93 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Y4MRecorder streams frames as uncompressed YUV4MPEG2 video with 4:4:4
+// chroma, which ffmpeg and most players read directly.
+type Y4MRecorder struct {
+	w         *bufio.Writer
+	frameRate int
+	header    bool
+	plane     []byte
+}
+
+// NewY4MRecorder returns a recorder that writes a Y4M stream to w.
+func NewY4MRecorder(w io.Writer, frameRate int) *Y4MRecorder {
+	return &Y4MRecorder{w: bufio.NewWriter(w), frameRate: frameRate}
+}
+
+func (y *Y4MRecorder) AddFrame(img *image.Paletted) error {
+	size := img.Rect.Size()
+	if !y.header {
+		fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C444\n", size.X, size.Y, y.frameRate)
+		y.header = true
+	}
+
+	// Convert the palette once, then look every pixel up in it.
+	yuv := make([][3]byte, len(img.Palette))
+	for i, c := range img.Palette {
+		r, g, b, _ := c.RGBA()
+		yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+		yuv[i] = [3]byte{yy, cb, cr}
+	}
+
+	if _, err := io.WriteString(y.w, "FRAME\n"); err != nil {
+		return err
+	}
+	for component := 0; component < 3; component++ {
+		y.plane = y.plane[:0]
+		for row := img.Rect.Min.Y; row < img.Rect.Max.Y; row++ {
+			for col := img.Rect.Min.X; col < img.Rect.Max.X; col++ {
+				y.plane = append(y.plane, yuv[img.ColorIndexAt(col, row)][component])
+			}
+		}
+		if _, err := y.w.Write(y.plane); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (y *Y4MRecorder) Close() error {
+	return y.w.Flush()
+}
+
+// RGBRecorder streams frames as packed 8-bit RGB with no header, suitable for
+// ffmpeg -f rawvideo -pixel_format rgb24.
+type RGBRecorder struct {
+	w     *bufio.Writer
+	frame []byte
+}
+
+// NewRGBRecorder returns a recorder that writes raw RGB frames to w.
+func NewRGBRecorder(w io.Writer) *RGBRecorder {
+	return &RGBRecorder{w: bufio.NewWriter(w)}
+}
+
+func (r *RGBRecorder) AddFrame(img *image.Paletted) error {
+	rgb := make([][3]byte, len(img.Palette))
+	for i, c := range img.Palette {
+		cr, cg, cb, _ := c.RGBA()
+		rgb[i] = [3]byte{uint8(cr >> 8), uint8(cg >> 8), uint8(cb >> 8)}
+	}
+
+	r.frame = r.frame[:0]
+	for row := img.Rect.Min.Y; row < img.Rect.Max.Y; row++ {
+		for col := img.Rect.Min.X; col < img.Rect.Max.X; col++ {
+			p := rgb[img.ColorIndexAt(col, row)]
+			r.frame = append(r.frame, p[0], p[1], p[2])
+		}
+	}
+	_, err := r.w.Write(r.frame)
+	return err
+}
+
+func (r *RGBRecorder) Close() error {
+	return r.w.Flush()
+}
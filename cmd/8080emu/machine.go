@@ -0,0 +1,255 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpm"
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+	"github.com/PetrusJPrinsloo/8080Emulator/sched"
+)
+
+// Machine types accepted by -machine.
+const (
+	machineInvaders = "invaders"
+	machineBare     = "bare"
+	machineCPM      = "cpm"
+)
+
+// address is a flag holding a 16-bit address, given in decimal or with a 0x
+// prefix in hex.
+type address uint16
+
+func (a *address) String() string {
+	return fmt.Sprintf("0x%04x", uint16(*a))
+}
+
+func (a *address) Set(s string) error {
+	v, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return fmt.Errorf("%q is not an address from 0 to 0xffff", s)
+	}
+	*a = address(v)
+	return nil
+}
+
+// logLevel is how much the emulator reports on stderr.
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var levelNames = []string{"error", "warn", "info", "debug"}
+
+func (l *logLevel) String() string {
+	return levelNames[*l]
+}
+
+func (l *logLevel) Set(s string) error {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			*l = logLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown log level %q (want %s)", s, strings.Join(levelNames, ", "))
+}
+
+// logger writes messages at or below its level to stderr.
+type logger struct {
+	level logLevel
+	out   *log.Logger
+}
+
+var logs = logger{level: levelWarn, out: log.New(os.Stderr, "8080emu: ", log.Ltime)}
+
+func (l *logger) logf(level logLevel, format string, args ...interface{}) {
+	if level <= l.level {
+		l.out.Printf(levelNames[level]+": "+format, args...)
+	}
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) { l.logf(levelWarn, format, args...) }
+func (l *logger) Infof(format string, args ...interface{}) { l.logf(levelInfo, format, args...) }
+
+// machineFlags are the flags shared by the commands that run a program.
+type machineFlags struct {
+	fs        *flag.FlagSet
+	machine   string
+	clock     int
+	load      address
+	pc        address
+	pcSet     bool
+	ignoreCRC bool
+}
+
+func (m *machineFlags) register(fs *flag.FlagSet) {
+	m.fs = fs
+	fs.StringVar(&m.machine, "machine", machineInvaders, "machine type: invaders (Space Invaders board), bare (CPU with 64 KiB of RAM) or cpm (CP/M console; the default for .com files)")
+	fs.IntVar(&m.clock, "clock", 0, "CPU clock in Hz (default 1996800 for invaders, 2000000 for bare; cpm runs unthrottled unless given)")
+	fs.Var(&m.load, "load", "`address` to load the program at (bare machine only)")
+	fs.Func("pc", "`address` to start executing at (default the load address)", func(s string) error {
+		m.pcSet = true
+		return m.pc.Set(s)
+	})
+	fs.BoolVar(&m.ignoreCRC, "ignore-crc", false, "load ROM set chips whose CRC32 does not match, such as modified programs, with a warning")
+	fs.Var(&logs.level, "log-level", "`level` of messages to show: error, warn, info or debug (debug logs every I/O access)")
+}
+
+// target is a machine built from the flags: either a Space Invaders board or
+// a bare CPU, driven by a scheduler in both cases.
+type target struct {
+	cpu       *cpu8080.State8080
+	ports     *cpu8080.Ports
+	scheduler *sched.Scheduler
+	invaders  *invaders.Machine // set for the invaders machine
+	cpm       *cpm.Machine      // set for the cpm machine
+}
+
+// open reads the program in path and builds a machine running it. The
+// program must fit in the memory the machine has for it: the ROM on the
+// invaders board, the TPA under CP/M, or everything from the load address up
+// on the bare machine. For the invaders board path may also be a directory
+// or zip archive holding the chips of the ROM set. Files ending in .com run
+// under CP/M unless -machine says otherwise.
+func (m *machineFlags) open(path string) (*target, error) {
+	if strings.EqualFold(filepath.Ext(path), ".com") && !isSet(m.fs, "machine") {
+		m.machine = machineCPM
+	}
+
+	base, end := uint16(m.load), memorySize
+	switch m.machine {
+	case machineInvaders:
+		end = invaders.ROMSize
+	case machineCPM:
+		if isSet(m.fs, "load") && m.load != cpm.TPA {
+			return nil, usagef("-load is not supported on the cpm machine, which loads programs at 0x%04x", cpm.TPA)
+		}
+		m.load = cpm.TPA
+		base, end = cpm.TPA, cpm.BDOSBase
+	}
+
+	var program []byte
+	var err error
+	if m.machine == machineInvaders && isROMSet(path) {
+		program, err = loadROMSet(invaders.Invaders, path, m.ignoreCRC)
+	} else {
+		program, err = RetrieveROM(path, base, end)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.build(program)
+}
+
+// build makes the machine described by the flags, with program loaded.
+func (m *machineFlags) build(program []byte) (*target, error) {
+	if m.clock < 0 {
+		return nil, usagef("clock must be positive, got %d", m.clock)
+	}
+	if !m.pcSet {
+		m.pc = m.load
+	}
+
+	var t target
+	switch m.machine {
+	case machineInvaders:
+		if m.load != 0 {
+			return nil, usagef("-load is not supported on the invaders machine, whose ROM is at 0x0000")
+		}
+		machine, err := invaders.New(program)
+		if err != nil {
+			return nil, err
+		}
+		if m.clock != 0 {
+			machine.SetClock(m.clock)
+		}
+		t = target{cpu: machine.CPU, ports: machine.Ports, scheduler: machine.Scheduler, invaders: machine}
+	case machineBare:
+		cpu := cpu8080.NewState8080(nil)
+		cpu.Load(uint16(m.load), program)
+		ports := cpu8080.NewPorts()
+		cpu.IO = ports
+		t = target{cpu: cpu, ports: ports, scheduler: sched.New(cpu, m.clock, invaders.FrameRate)}
+	case machineCPM:
+		machine, err := cpm.New(program)
+		if err != nil {
+			return nil, err
+		}
+		machine.Out = os.Stdout
+		machine.In = os.Stdin
+		ports := cpu8080.NewPorts()
+		machine.CPU.IO = ports
+		t = target{cpu: machine.CPU, ports: ports, scheduler: sched.New(machine, m.clock, invaders.FrameRate), cpm: machine}
+		// test programs take minutes at 2 MHz, so only keep to real
+		// time when a clock speed is asked for
+		t.scheduler.Throttle = m.clock != 0
+	default:
+		return nil, usagef("unknown machine %q (want %s, %s or %s)", m.machine, machineInvaders, machineBare, machineCPM)
+	}
+	t.cpu.PC = uint16(m.pc)
+
+	if logs.level >= levelDebug {
+		t.ports.Logger = logs.out
+	}
+	logs.Infof("%s machine, %d bytes loaded at 0x%04x, starting at 0x%04x, clock %d Hz",
+		m.machine, len(program), uint16(m.load), uint16(m.pc), t.scheduler.ClockHz())
+	return &t, nil
+}
+
+// frame counts the frames the machine has run.
+func (t *target) frame() uint64 {
+	return t.scheduler.Frame
+}
+
+// cycles returns the number of CPU cycles run, including any before a
+// watchdog reset.
+func (t *target) cycles() uint64 {
+	if t.invaders != nil {
+		return t.invaders.Cycles()
+	}
+	return t.cpu.Cycles
+}
+
+// idleError reports a CPU halted with interrupts enabled on a machine with
+// nothing to interrupt it, which would otherwise idle forever. It matches
+// cpu8080.ErrHalted.
+type idleError struct {
+	pc uint16
+}
+
+func (e *idleError) Error() string {
+	return fmt.Sprintf("cpu halted at %04X waiting for an interrupt that nothing raises", e.pc)
+}
+
+func (e *idleError) Is(target error) bool {
+	return target == cpu8080.ErrHalted
+}
+
+// runFrame runs one frame of the machine. Only the invaders board raises
+// interrupts, so on the others a CPU halted with interrupts enabled stops
+// the machine with an idleError.
+func (t *target) runFrame() error {
+	if t.invaders == nil && t.cpu.Halted && !t.cpu.InterruptPending() {
+		return &idleError{pc: t.cpu.PC - 1}
+	}
+	if t.invaders != nil {
+		resets := t.invaders.WatchdogResets
+		err := t.invaders.RunFrame()
+		if t.invaders.WatchdogResets != resets {
+			logs.Warnf("watchdog reset the CPU at frame %d", t.invaders.Frame)
+		}
+		return err
+	}
+	return t.scheduler.RunFrame()
+}
@@ -0,0 +1,152 @@
+// Command 8080emu runs and inspects Intel 8080 programs: the Space Invaders
+// arcade board, or bare code on a CPU with 64 KiB of RAM.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is one of the emulator's subcommands.
+type command struct {
+	name    string
+	args    string // synopsis of the positional arguments
+	summary string
+	run     func(fs *flag.FlagSet, args []string) error
+}
+
+var commands = []*command{
+	{"run", "<rom>", "Run a program, by default on the Space Invaders board.", runCommand},
+	{"disasm", "<file>", "Disassemble a ROM or program file.", disasmCommand},
+	{"trace", "<rom>", "Run a program, printing every instruction and the registers.", traceCommand},
+	{"test", "[program...]", "Run the 8080 exercisers (TST8080, CPUTEST, 8080PRE, 8080EXM, CPUDIAG) or other programs and report whether each passed.", testCommand},
+	{"bench", "<rom>", "Run a program as fast as possible and report the speed.", benchCommand},
+	{"info", "<file>...", "Describe ROM files or ROM set directories and zips: size, checksums and first instructions.", infoCommand},
+}
+
+// usageError reports bad command-line arguments. main prints it, if it has a
+// message, and exits with status 2.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: 8080emu <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"8080emu help <command>\" or \"8080emu <command> -h\" for the flags of a command.")
+	fmt.Fprintln(w, "\"8080emu [flags] <rom>\" is short for \"8080emu run [flags] <rom>\".")
+}
+
+func lookup(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// flagSet returns an empty flag set for cmd whose usage message describes
+// the command. The command defines its flags when it runs.
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: 8080emu %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if cmd := lookup(args[1]); cmd != nil {
+				// the flags are defined by the command itself
+				fs := cmd.flagSet()
+				fs.SetOutput(os.Stdout)
+				cmd.run(fs, []string{"-h"})
+				return
+			}
+			fmt.Fprintf(os.Stderr, "8080emu: unknown command %q\n", args[1])
+			os.Exit(2)
+		}
+		usage(os.Stdout)
+		return
+	}
+
+	cmd := lookup(name)
+	if cmd == nil {
+		// Older versions took the ROM as the only argument, so flags or
+		// an existing file mean run.
+		if _, err := os.Stat(name); err != nil && !strings.HasPrefix(name, "-") {
+			fmt.Fprintf(os.Stderr, "8080emu: unknown command %q\n\n", name)
+			usage(os.Stderr)
+			os.Exit(2)
+		}
+		cmd = lookup("run")
+	} else {
+		args = args[1:]
+	}
+
+	fs := cmd.flagSet()
+	err := cmd.run(fs, args)
+	var uerr usageError
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &uerr):
+		if uerr.msg != "" {
+			fmt.Fprintf(os.Stderr, "8080emu %s: %s\n", cmd.name, uerr.msg)
+			fmt.Fprintf(os.Stderr, "Run \"8080emu help %s\" for usage.\n", cmd.name)
+		}
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "8080emu %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+// parse parses args into fs and checks the number of positional arguments,
+// which must be between min and max (no limit if max < 0).
+func parse(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		// the flag package has already printed the error and usage
+		return usageError{}
+	}
+	switch n := fs.NArg(); {
+	case n < min:
+		return usagef("missing arguments")
+	case max >= 0 && n > max:
+		return usagef("too many arguments: %s", strings.Join(fs.Args()[max:], " "))
+	}
+	return nil
+}
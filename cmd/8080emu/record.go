@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/capture"
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+)
+
+// frameRecorder feeds the frames between start and stop to a capture
+// recorder.
+type frameRecorder struct {
+	recorder    capture.Recorder
+	out         io.WriteCloser
+	start, stop uint64
+}
+
+// openRecorder opens the recording file, or stdout when path is "-". The
+// format is taken from the file extension when not given.
+func openRecorder(path, format string, start, stop uint64) (*frameRecorder, error) {
+	if format == "" {
+		var err error
+		if format, err = capture.FormatFromPath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	out := io.WriteCloser(os.Stdout)
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	recorder, err := capture.New(format, out, invaders.FrameRate)
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	return &frameRecorder{recorder: recorder, out: out, start: start, stop: stop}, nil
+}
+
+// Frame records the machine's screen if the frame just completed is in the
+// recorded range.
+func (r *frameRecorder) Frame(machine *invaders.Machine) error {
+	if machine.Frame < r.start || (r.stop != 0 && machine.Frame > r.stop) {
+		return nil
+	}
+	return r.recorder.AddFrame(machine.Screen())
+}
+
+// Close finishes the recording and closes its file.
+func (r *frameRecorder) Close() error {
+	err := r.recorder.Close()
+	if r.out != os.Stdout {
+		if cerr := r.out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
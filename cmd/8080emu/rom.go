@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+)
+
+// memorySize is the size of the 8080's address space.
+const memorySize = 0x10000
+
+// RetrieveROM reads the whole of filename as a program image to be loaded at
+// base, and checks that it fits below end, the first address past the memory
+// the image may occupy.
+func RetrieveROM(filename string, base uint16, end int) ([]byte, error) {
+	room := end - int(base)
+	if room <= 0 {
+		return nil, fmt.Errorf("%s: load address 0x%04x is outside memory, which ends at 0x%04x", filename, base, end-1)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory, not a program file", filename)
+	}
+
+	// Reading one byte more than fits is enough to tell that a file is too
+	// big without reading all of it.
+	data, err := io.ReadAll(io.LimitReader(file, int64(room)+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	switch {
+	case len(data) == 0:
+		return nil, fmt.Errorf("%s: file is empty", filename)
+	case len(data) > room:
+		size := fmt.Sprintf("more than %d bytes", room)
+		if info, err := file.Stat(); err == nil {
+			size = fmt.Sprintf("%d bytes", info.Size())
+		}
+		return nil, fmt.Errorf("%s: %s do not fit at 0x%04x; at most %d bytes fit below 0x%04x",
+			filename, size, base, room, end)
+	}
+	return data, nil
+}
+
+// isROMSet reports whether path names a directory or zip archive of ROM
+// chips rather than a single program image.
+func isROMSet(path string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// loadROMSet loads the chips of set from path. A chip that does not match its
+// checksum is an error unless ignoreCRC is set, in which case it is used
+// with a warning so that modified programs run.
+func loadROMSet(set invaders.ROMSet, path string, ignoreCRC bool) ([]byte, error) {
+	image, err := set.Load(path)
+	var setErr *invaders.ROMSetError
+	if errors.As(err, &setErr) && image != nil {
+		if !ignoreCRC {
+			return nil, fmt.Errorf("%w; use -ignore-crc to load it anyway", err)
+		}
+		for _, chip := range setErr.Chips {
+			logs.Warnf("%s: %v", path, chip)
+		}
+		return image, nil
+	}
+	return image, err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRetrieveROM(t *testing.T) {
+	dir := t.TempDir()
+	program := func(n int) []byte {
+		return bytes.Repeat([]byte{0x76}, n)
+	}
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	empty := write("empty.bin", nil)
+	fits := write("fits.bin", program(0x10))
+	tooBig := write("big.bin", program(0x11))
+	small := write("small.bin", program(3))
+
+	tests := []struct {
+		name string
+		path string
+		base uint16
+		end  int
+		want []byte
+		err  string // the error message, if any
+	}{
+		{"empty file", empty, 0x0010, 0x0020, nil, empty + ": file is empty"},
+		{"directory", dir, 0x0000, memorySize, nil, dir + " is a directory, not a program file"},
+		{"exactly fits", fits, 0x0010, 0x0020, program(0x10), ""},
+		{"fills memory", fits, 0xfff0, memorySize, program(0x10), ""},
+		{"one byte too many", tooBig, 0x0010, 0x0020, nil,
+			tooBig + ": 17 bytes do not fit at 0x0010; at most 16 bytes fit below 0x0020"},
+		{"smaller than room", small, 0x0100, memorySize, program(3), ""},
+		{"base at end", small, 0x0020, 0x0020, nil,
+			small + ": load address 0x0020 is outside memory, which ends at 0x001f"},
+		{"base past end", small, 0x0100, 0x0020, nil,
+			small + ": load address 0x0100 is outside memory, which ends at 0x001f"},
+	}
+	for _, tc := range tests {
+		data, err := RetrieveROM(tc.path, tc.base, tc.end)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			} else if !bytes.Equal(data, tc.want) {
+				t.Errorf("%s: read % x, want % x", tc.name, data, tc.want)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: read %d bytes, want error %q", tc.name, len(data), tc.err)
+			continue
+		}
+		if err.Error() != tc.err {
+			t.Errorf("%s: error %q, want %q", tc.name, err, tc.err)
+		}
+		if data != nil {
+			t.Errorf("%s: returned data with an error", tc.name)
+		}
+	}
+
+	if _, err := RetrieveROM(filepath.Join(dir, "missing.bin"), 0, memorySize); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: error %v, want one matching os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpm"
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+	"github.com/PetrusJPrinsloo/8080Emulator/terminal"
+)
+
+func runCommand(fs *flag.FlagSet, args []string) error {
+	var mf machineFlags
+	mf.register(fs)
+	screenshot := fs.String("screenshot", "screenshot-%d.png", "PNG file for screenshots; %d is replaced by the frame number")
+	screenshotFrame := fs.Int64("screenshot-frame", -1, "take a screenshot when this frame completes")
+	frames := fs.Uint64("frames", 0, "stop after this many frames (0 runs until halted)")
+	display := fs.String("display", "none", "screen output: none, halfblock or braille (terminal)")
+	overlay := fs.String("overlay", "mono", "screen colours: "+strings.Join(invaders.OverlayNames(), ", ")+", or a JSON overlay file")
+	record := fs.String("record", "", "record video to this file, or - for stdout")
+	recordFormat := fs.String("record-format", "", "recording format: gif, y4m or rgb (default from the -record file extension)")
+	recordStart := fs.Uint64("record-start", 0, "first frame to record")
+	recordStop := fs.Uint64("record-stop", 0, "stop recording after this frame (0 records until exit)")
+	audioOut := fs.String("audio", "", "write sound to this WAV file, or raw PCM to stdout with -")
+	samples := fs.String("samples", "", "directory holding the sound samples 0.wav to 9.wav (default: synthesized sound)")
+	audioRate := fs.Int("audio-rate", 44100, "audio sample rate in Hz")
+	unthrottled := fs.Bool("unthrottled", false, "run as fast as possible instead of in real time")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	t, err := mf.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *unthrottled {
+		t.scheduler.Throttle = false
+	}
+
+	if t.invaders == nil {
+		for _, name := range []string{"screenshot", "screenshot-frame", "display", "overlay", "record", "audio", "samples"} {
+			if isSet(fs, name) {
+				return usagef("-%s needs the invaders machine", name)
+			}
+		}
+		return runBare(t, *frames)
+	}
+
+	var toStdout []string
+	for _, out := range []struct{ name, value string }{{"display", *display}, {"record", *record}, {"audio", *audioOut}} {
+		if out.value == "-" || (out.name == "display" && out.value != "none") {
+			toStdout = append(toStdout, "-"+out.name)
+		}
+	}
+	if len(toStdout) > 1 {
+		return usagef("only one of %s can write to stdout", strings.Join(toStdout, ", "))
+	}
+
+	machine := t.invaders
+	colours, err := invaders.LoadOverlay(*overlay)
+	if err != nil {
+		return err
+	}
+	machine.SetOverlay(colours)
+
+	mode := terminal.HalfBlock
+	switch *display {
+	case "none", "halfblock":
+	case "braille":
+		mode = terminal.Braille
+	default:
+		return usagef("unknown display %q", *display)
+	}
+
+	var recorder *frameRecorder
+	if *record != "" {
+		if recorder, err = openRecorder(*record, *recordFormat, *recordStart, *recordStop); err != nil {
+			return err
+		}
+	}
+
+	var sound *soundOutput
+	if *audioOut != "" {
+		if sound, err = openSound(*audioOut, *samples, *audioRate); err != nil {
+			if recorder != nil {
+				recorder.Close()
+			}
+			return err
+		}
+		sound.ClockHz = t.scheduler.ClockHz()
+		machine.Sound = sound
+	}
+
+	// The terminal goes into raw mode last, so that nothing can fail and
+	// return without restoring it.
+	var frontend *terminalFrontend
+	if *display != "none" {
+		if frontend, err = newTerminalFrontend(mode); err != nil {
+			if sound != nil {
+				sound.Close()
+			}
+			if recorder != nil {
+				recorder.Close()
+			}
+			return err
+		}
+	}
+
+	err = run(t, frontend, recorder, *screenshot, *screenshotFrame, *frames)
+	if frontend != nil {
+		frontend.Close()
+	}
+	if sound != nil {
+		if serr := sound.Close(); err == nil {
+			err = serr
+		}
+	}
+	if recorder != nil {
+		if rerr := recorder.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return halted(err)
+}
+
+// halted treats a CPU stopped by HLT as a normal exit, reporting on stderr
+// where it stopped, and likewise a CP/M program that has exited.
+func halted(err error) error {
+	switch {
+	case errors.Is(err, cpu8080.ErrHalted):
+		fmt.Fprintln(os.Stderr, err)
+		return nil
+	case errors.Is(err, cpm.ErrWarmBoot):
+		return nil
+	}
+	return err
+}
+
+// runBare runs a bare or CP/M machine until it halts or the frame limit is reached.
+func runBare(t *target, frames uint64) error {
+	for frames == 0 || t.frame() < frames {
+		if err := t.runFrame(); err != nil {
+			return halted(err)
+		}
+		t.scheduler.Sync()
+	}
+	return nil
+}
+
+// run emulates frames until the machine halts, the frame limit is reached or
+// the player quits.
+func run(t *target, frontend *terminalFrontend, recorder *frameRecorder,
+	screenshot string, screenshotFrame int64, frames uint64) error {
+	machine := t.invaders
+	// SIGUSR1 takes a screenshot on demand
+	requests := screenshotRequests()
+
+	// runFrame raises the frame's interrupts; Sync then waits for the
+	// frame's slot in real time unless running unthrottled
+	for {
+		if err := t.runFrame(); err != nil {
+			return err
+		}
+
+		if frontend != nil {
+			running, err := frontend.Update(machine)
+			if err != nil || !running {
+				return err
+			}
+		}
+
+		if recorder != nil {
+			if err := recorder.Frame(machine); err != nil {
+				return err
+			}
+		}
+
+		take := int64(machine.Frame) == screenshotFrame
+		select {
+		case <-requests:
+			take = true
+		default:
+		}
+		if take {
+			if err := machine.Screenshot(screenshot); err != nil {
+				return err
+			}
+		}
+
+		if frames != 0 && machine.Frame >= frames {
+			return nil
+		}
+		t.scheduler.Sync()
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// screenshotRequests returns nil: there is no SIGUSR1 on this platform, so
+// screenshots can only be taken at a chosen frame.
+func screenshotRequests() <-chan os.Signal {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// screenshotRequests returns a channel that receives whenever the process is
+// sent SIGUSR1.
+func screenshotRequests() <-chan os.Signal {
+	requests := make(chan os.Signal, 1)
+	signal.Notify(requests, syscall.SIGUSR1)
+	return requests
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/audio"
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+)
+
+// soundOutput is a sound board together with the file it writes to.
+type soundOutput struct {
+	*invaders.SoundBoard
+	file io.Closer
+}
+
+// openSound builds a sound board from the sample pack in samples, or from the
+// synthesizer when samples is empty, and writes its output to path: a WAV
+// file, or raw PCM when path is "-" or ends in .pcm or .raw.
+func openSound(path, samples string, rate int) (*soundOutput, error) {
+	var voices invaders.Voices = invaders.NewSynth(rate)
+	if samples != "" {
+		player, err := invaders.LoadSamples(samples, rate)
+		if err != nil {
+			return nil, err
+		}
+		voices = player
+	}
+
+	var out io.Writer = os.Stdout
+	var file io.Closer
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		out, file = f, f
+	}
+
+	var writer audio.Writer
+	switch ext := strings.ToLower(filepath.Ext(path)); {
+	case path == "-" || ext == ".pcm" || ext == ".raw":
+		writer = audio.NewPCMWriter(out)
+	default:
+		writer = audio.NewWAVWriter(out, rate)
+	}
+	return &soundOutput{SoundBoard: invaders.NewSoundBoard(voices, writer, rate), file: file}, nil
+}
+
+// Close flushes the audio and closes its file.
+func (s *soundOutput) Close() error {
+	err := s.SoundBoard.Close()
+	if s.file != nil {
+		if cerr := s.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+	"github.com/PetrusJPrinsloo/8080Emulator/terminal"
+)
+
+// holdFrames is how long a key press holds its button down. Terminals only
+// report presses, so a held key is seen as a stream of repeated presses.
+const holdFrames = 8
+
+// terminalKeys maps keys to cabinet buttons.
+var terminalKeys = map[terminal.Key]invaders.Button{
+	'c':               invaders.Coin,
+	'5':               invaders.Coin,
+	'1':               invaders.Start1,
+	'2':               invaders.Start2,
+	' ':               invaders.Fire1,
+	terminal.KeyLeft:  invaders.Left1,
+	'a':               invaders.Left1,
+	terminal.KeyRight: invaders.Right1,
+	'd':               invaders.Right1,
+	'j':               invaders.Left2,
+	'l':               invaders.Right2,
+	'k':               invaders.Fire2,
+	't':               invaders.Tilt,
+}
+
+// terminalFrontend draws the screen on the controlling terminal and turns
+// key presses into button presses.
+type terminalFrontend struct {
+	renderer *terminal.Renderer
+	restore  func() error
+	keys     <-chan terminal.Key
+	held     map[invaders.Button]int
+}
+
+func newTerminalFrontend(mode terminal.Mode) (*terminalFrontend, error) {
+	restore, err := terminal.MakeRaw(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return &terminalFrontend{
+		renderer: terminal.NewRenderer(os.Stdout, mode),
+		restore:  restore,
+		keys:     terminal.ReadKeys(os.Stdin),
+		held:     make(map[invaders.Button]int),
+	}, nil
+}
+
+// Update applies the keys pressed since the last frame, releases buttons
+// whose hold has expired and redraws the screen. It reports false once the
+// player asks to quit.
+func (t *terminalFrontend) Update(machine *invaders.Machine) (bool, error) {
+	for button, frames := range t.held {
+		if frames <= 1 {
+			machine.SetButton(button, false)
+			delete(t.held, button)
+		} else {
+			t.held[button] = frames - 1
+		}
+	}
+
+	for pending := true; pending; {
+		select {
+		case key, ok := <-t.keys:
+			if !ok || key == 'q' || key == terminal.KeyCtrlC {
+				return false, nil
+			}
+			if button, ok := terminalKeys[key]; ok {
+				machine.SetButton(button, true)
+				t.held[button] = holdFrames
+			}
+		default:
+			pending = false
+		}
+	}
+
+	return true, t.renderer.Draw(machine.Screen())
+}
+
+// Close restores the terminal.
+func (t *terminalFrontend) Close() error {
+	t.renderer.Close()
+	return t.restore()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/conformance"
+	"github.com/PetrusJPrinsloo/8080Emulator/cpm"
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+)
+
+func testCommand(fs *flag.FlagSet, args []string) error {
+	var mf machineFlags
+	mf.register(fs)
+	maxCycles := fs.Uint64("max-cycles", conformance.DefaultMaxCycles, "fail a program that runs for more than this many cycles")
+	dir := fs.String("dir", "testdata", "directory searched for the known exercisers when no programs are given")
+	verbose := fs.Bool("v", false, "show the output of the exercisers as they run")
+	if err := parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+	if mf.machine == machineInvaders && !isSet(fs, "machine") {
+		mf.machine = machineBare
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = findExercisers(*dir)
+		if len(paths) == 0 {
+			return fmt.Errorf("no programs given, and none of the known exercisers are in %s", *dir)
+		}
+	}
+
+	failed := 0
+	for _, path := range paths {
+		var passed bool
+		var err error
+		if program, ok := conformance.Lookup(path); ok && (mf.machine == machineCPM || !isSet(fs, "machine")) {
+			passed, err = runExerciser(program, path, *verbose, *maxCycles)
+		} else {
+			// open picks the machine and load address for each path, so
+			// give it a copy that does not carry them on to the next one
+			pathFlags := mf
+			passed, err = runUntilHalt(&pathFlags, path, *maxCycles)
+		}
+		if err != nil {
+			return err
+		}
+		if !passed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d programs failed", failed, len(paths))
+	}
+	return nil
+}
+
+// findExercisers returns the paths of the known exercisers in dir.
+func findExercisers(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, p := range conformance.Programs {
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), p.Name) {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return paths
+}
+
+// runExerciser runs one of the known exercisers under CP/M and reports the
+// result of each of its test groups.
+func runExerciser(program conformance.Program, path string, verbose bool, maxCycles uint64) (bool, error) {
+	code, err := RetrieveROM(path, cpm.TPA, cpm.BDOSBase)
+	if err != nil {
+		return false, err
+	}
+	var echo io.Writer
+	if verbose {
+		echo = os.Stdout
+	}
+	r, err := program.Run(code, echo, maxCycles)
+	if err != nil {
+		return false, err
+	}
+	if verbose {
+		fmt.Println()
+	}
+
+	fmt.Printf("%s %s: %d instructions, %d cycles, %v\n",
+		verdict(r.Passed), path, r.Instructions, r.Cycles, r.Elapsed.Round(time.Millisecond))
+	for _, g := range r.Groups {
+		fmt.Printf("    %s %s  %s\n", verdict(g.Passed), g.Name, g.Detail)
+	}
+	if !r.Passed {
+		fmt.Printf("    %s\n", r.Reason)
+	}
+	return r.Passed, nil
+}
+
+// runUntilHalt runs any other program on the machine chosen by the flags and
+// passes it if it halts or exits before maxCycles.
+func runUntilHalt(mf *machineFlags, path string, maxCycles uint64) (bool, error) {
+	t, err := mf.open(path)
+	if err != nil {
+		return false, err
+	}
+	t.scheduler.Throttle = false
+	var instructions uint64
+	t.cpu.Trace = func(*cpu8080.State8080) { instructions++ }
+
+	start := time.Now()
+	for t.cycles() < maxCycles && err == nil {
+		err = t.runFrame()
+	}
+	elapsed := time.Since(start)
+
+	switch {
+	case errors.Is(err, cpu8080.ErrHalted), errors.Is(err, cpm.ErrWarmBoot):
+		err = nil
+	case err == nil:
+		err = fmt.Errorf("still running after %d cycles", t.cycles())
+	}
+	fmt.Printf("%s %s: %d instructions, %d cycles, %v\n", verdict(err == nil), path, instructions, t.cycles(), elapsed.Round(time.Millisecond))
+	if err != nil {
+		fmt.Printf("    %v\n", err)
+	}
+	return err == nil, nil
+}
+
+func verdict(passed bool) string {
+	if passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
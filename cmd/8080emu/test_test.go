@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// quiet sends stdout to the null device for the rest of the test.
+func quiet(t *testing.T) {
+	t.Helper()
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = null
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		null.Close()
+	})
+}
+
+func TestTestMixedMachines(t *testing.T) {
+	quiet(t)
+	dir := t.TempDir()
+	com := filepath.Join(dir, "a.com")
+	bin := filepath.Join(dir, "b.bin")
+	// a.com returns to CP/M. b.bin halts on the bare machine, where 0005
+	// is its own HLT, but under CP/M it calls the unsupported BDOS
+	// function 15 and fails.
+	if err := os.WriteFile(com, []byte{0xc9}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bare := []byte{
+		0x0e, 0x0f, // MVI C,15
+		0xcd, 0x05, 0x00, // CALL 0005
+		0x76, // HLT
+	}
+	if err := os.WriteFile(bin, bare, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, paths := range [][]string{{com, bin}, {bin, com}, {com, bin, com, bin}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if err := testCommand(fs, paths); err != nil {
+			t.Errorf("test %v: %v", paths, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/sha1"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpm"
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+	"github.com/PetrusJPrinsloo/8080Emulator/invaders"
+)
+
+// listing formats the instruction at addr as a line of a disassembly listing:
+// address, instruction bytes and assembly.
+func listing(bus cpu8080.Bus, addr uint16) (string, int) {
+	text, n := cpu8080.Disassemble(bus, addr)
+	var hex strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&hex, "%02X ", bus.Read(addr+uint16(i)))
+	}
+	return fmt.Sprintf("%04X  %-9s %s", addr, hex.String(), text), n
+}
+
+func disasmCommand(fs *flag.FlagSet, args []string) error {
+	var load, start address
+	var startSet bool
+	fs.Var(&load, "load", "`address` the file is loaded at")
+	fs.Func("start", "`address` to start disassembling at (default the load address)", func(s string) error {
+		startSet = true
+		return start.Set(s)
+	})
+	count := fs.Int("count", 0, "number of instructions to disassemble (0 disassembles to the end of the file)")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	if !startSet {
+		start = load
+	}
+
+	program, err := RetrieveROM(fs.Arg(0), uint16(load), memorySize)
+	if err != nil {
+		return err
+	}
+	ram := cpu8080.NewRAM(memorySize)
+	copy(ram[load:], program)
+	end := int(load) + len(program)
+	if int(start) < int(load) || int(start) >= end {
+		return fmt.Errorf("start address 0x%04x is outside the file (0x%04x to 0x%04x)", uint16(start), uint16(load), end-1)
+	}
+
+	addr := int(start)
+	for i := 0; addr < end && (*count == 0 || i < *count); i++ {
+		line, n := listing(ram, uint16(addr))
+		fmt.Println(line)
+		addr += n
+	}
+	return nil
+}
+
+func traceCommand(fs *flag.FlagSet, args []string) error {
+	var mf machineFlags
+	mf.register(fs)
+	steps := fs.Uint64("steps", 1000, "number of instructions to trace (0 traces until halted)")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	t, err := mf.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	t.scheduler.Throttle = false
+
+	var traced uint64
+	t.cpu.Trace = func(cpu *cpu8080.State8080) {
+		if *steps != 0 && traced >= *steps {
+			return
+		}
+		line, _ := listing(cpu.Bus, cpu.PC)
+		fmt.Printf("%-32s %s CYC=%d\n", line, cpu.Registers(), cpu.Cycles)
+		traced++
+	}
+	for *steps == 0 || traced < *steps {
+		if err := t.runFrame(); err != nil {
+			return halted(err)
+		}
+	}
+	return nil
+}
+
+// isSet reports whether the flag called name was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func benchCommand(fs *flag.FlagSet, args []string) error {
+	var mf machineFlags
+	mf.register(fs)
+	frames := fs.Uint64("frames", 3600, "number of frames to run")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	if *frames == 0 {
+		return usagef("-frames must be at least 1")
+	}
+
+	t, err := mf.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	t.scheduler.Throttle = false
+
+	start := time.Now()
+	for t.frame() < *frames {
+		if err := t.runFrame(); err != nil {
+			if errors.Is(err, cpu8080.ErrHalted) || errors.Is(err, cpm.ErrWarmBoot) {
+				return fmt.Errorf("program stopped after %d frames: %w", t.frame(), err)
+			}
+			return err
+		}
+	}
+	elapsed := time.Since(start)
+
+	emulated := float64(t.frame()) / float64(invaders.FrameRate)
+	fmt.Printf("%d frames, %d cycles in %v\n", t.frame(), t.cycles(), elapsed.Round(time.Millisecond))
+	fmt.Printf("%.0f frames/s, %.2f MHz effective, %.1fx real time\n",
+		float64(t.frame())/elapsed.Seconds(), float64(t.cycles())/elapsed.Seconds()/1e6, emulated/elapsed.Seconds())
+	return nil
+}
+
+// checkROMSet prints the state of each chip of set in path and returns the
+// image if every chip is present.
+func checkROMSet(set invaders.ROMSet, path string) ([]byte, error) {
+	image, err := set.Load(path)
+	var setErr *invaders.ROMSetError
+	if err != nil && !errors.As(err, &setErr) {
+		return nil, err
+	}
+	for _, chip := range set.Chips {
+		status := "ok"
+		if setErr != nil {
+			for _, c := range setErr.Chips {
+				if c.Chip == chip {
+					status = c.Err.Error()
+					if c.Detail != "" {
+						status += " (" + c.Detail + ")"
+					}
+				}
+			}
+		}
+		fmt.Printf("  chip:  %-10s at 0x%04x  %s\n", chip.Name, chip.Address, status)
+	}
+	if image == nil {
+		return nil, fmt.Errorf("%s: ROM set %s is incomplete", path, set.Name)
+	}
+	return image, nil
+}
+
+func infoCommand(fs *flag.FlagSet, args []string) error {
+	count := fs.Int("count", 8, "number of instructions to disassemble from the start of each file")
+	if err := parse(fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	for i, path := range fs.Args() {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s\n", path)
+
+		var data []byte
+		var err error
+		if isROMSet(path) {
+			data, err = checkROMSet(invaders.Invaders, path)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  size:  %d bytes (0x%04x)\n", len(data), len(data))
+		fmt.Printf("  crc32: %08x\n", crc32.ChecksumIEEE(data))
+		fmt.Printf("  sha1:  %x\n", sha1.Sum(data))
+		switch {
+		case len(data) == invaders.ROMSize:
+			fmt.Println("  fits:  Space Invaders program ROM (8 KiB)")
+		case len(data) <= memorySize:
+			fmt.Println("  fits:  bare machine (64 KiB)")
+		default:
+			fmt.Println("  fits:  nothing; larger than the 64 KiB address space")
+		}
+
+		ram := cpu8080.NewRAM(memorySize)
+		copy(ram, data)
+		addr := 0
+		for n := 0; n < *count && addr < len(data); n++ {
+			line, size := listing(ram, uint16(addr))
+			fmt.Printf("  %s\n", line)
+			addr += size
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package conformance runs the classic 8080 exerciser programs under CP/M and
+// judges their results. The programs themselves are not included; see
+// Programs for the ones it knows.
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpm"
+)
+
+// Program describes an exerciser and the output that tells whether the CPU
+// passed it.
+type Program struct {
+	Name string // file name, matched without regard to case
+	// Pass is printed only when every test has passed.
+	Pass string
+	// Fail lists output that shows a test has failed.
+	Fail []string
+	// Groups is set for programs that print a line of PASSED or ERROR for
+	// each group of instructions, which Run reports separately.
+	Groups bool
+	// Long is set for programs that take minutes rather than seconds.
+	Long bool
+}
+
+// Programs lists the exercisers this package knows how to judge. 8080EXM
+// checks a CRC of the results of each instruction group against the value
+// recorded from a real 8080, so its PASSED lines are comparisons against
+// known-good hardware.
+var Programs = []Program{
+	{Name: "TST8080.COM", Pass: "CPU IS OPERATIONAL", Fail: []string{"CPU HAS FAILED"}},
+	{Name: "CPUTEST.COM", Pass: "CPU TESTS OK"},
+	{Name: "8080PRE.COM", Pass: "Preliminary tests complete"},
+	{Name: "8080EXM.COM", Pass: "Tests complete", Fail: []string{"ERROR"}, Groups: true, Long: true},
+	{Name: "CPUDIAG.COM", Pass: "CPU IS OPERATIONAL", Fail: []string{"CPU HAS FAILED"}},
+}
+
+// Lookup returns the program whose name matches the base name of path.
+func Lookup(path string) (Program, bool) {
+	base := filepath.Base(path)
+	for _, p := range Programs {
+		if strings.EqualFold(p.Name, base) {
+			return p, true
+		}
+	}
+	return Program{}, false
+}
+
+// DefaultMaxCycles is enough for the longest exerciser, 8080EXM, with room
+// to spare.
+const DefaultMaxCycles = 100_000_000_000
+
+// Group is the result of one instruction group of a program with Groups set.
+type Group struct {
+	Name   string
+	Passed bool
+	Detail string // the rest of the line, such as the CRCs
+}
+
+// Result is the outcome of running a program.
+type Result struct {
+	Program      Program
+	Passed       bool
+	Reason       string // why the program failed, when it did
+	Output       string // everything the program printed
+	Groups       []Group
+	Instructions uint64 // instructions executed, counting each BDOS call as one
+	Cycles       uint64
+	Elapsed      time.Duration
+	Err          error // the error that stopped the program, if not a clean exit
+}
+
+// Run runs code as p under CP/M until it exits or has run maxCycles cycles,
+// copying its console output to echo if that is not nil, and judges the
+// result. The error is only for a program that cannot be loaded.
+func (p Program) Run(code []byte, echo io.Writer, maxCycles uint64) (*Result, error) {
+	m, err := cpm.New(code)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	m.Out = &output
+	if echo != nil {
+		m.Out = io.MultiWriter(&output, echo)
+	}
+
+	r := &Result{Program: p}
+	start := time.Now()
+	for m.CPU.Cycles < maxCycles {
+		if _, err = m.Step(); err != nil {
+			break
+		}
+		r.Instructions++
+	}
+	r.Elapsed = time.Since(start)
+	r.Cycles = m.CPU.Cycles
+	r.Output = output.String()
+	if p.Groups {
+		r.Groups = parseGroups(r.Output)
+	}
+
+	switch {
+	case err == nil:
+		r.Err = fmt.Errorf("still running after %d cycles", r.Cycles)
+	case !errors.Is(err, cpm.ErrWarmBoot):
+		r.Err = err
+	}
+	r.Passed, r.Reason = r.judge()
+	return r, nil
+}
+
+// judge decides whether the run passed and, if not, why.
+func (r *Result) judge() (bool, string) {
+	if r.Err != nil {
+		return false, r.Err.Error()
+	}
+	for _, fail := range r.Program.Fail {
+		if strings.Contains(r.Output, fail) {
+			return false, fmt.Sprintf("printed %q", fail)
+		}
+	}
+	for _, g := range r.Groups {
+		if !g.Passed {
+			return false, fmt.Sprintf("group %s failed", g.Name)
+		}
+	}
+	if !strings.Contains(r.Output, r.Program.Pass) {
+		return false, fmt.Sprintf("exited without printing %q", r.Program.Pass)
+	}
+	return true, ""
+}
+
+// parseGroups picks the per-group lines out of exerciser output such as
+//
+//	dad <b,d,h,sp>................  PASSED! crc is:14474ba6
+//	aluop nn......................  ERROR **** crc expected:9e922f9e found:1a2b3c4d
+func parseGroups(output string) []Group {
+	var groups []Group
+	for _, line := range strings.FieldsFunc(output, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		dots := strings.Index(line, "...")
+		if dots < 0 {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimLeft(line[dots:], "."))
+		g := Group{Name: strings.TrimSpace(line[:dots])}
+		switch {
+		case strings.HasPrefix(rest, "PASSED"):
+			g.Passed = true
+			g.Detail = strings.TrimSpace(strings.TrimPrefix(rest, "PASSED!"))
+		case strings.HasPrefix(rest, "ERROR"):
+			g.Detail = strings.TrimSpace(strings.TrimLeft(strings.TrimPrefix(rest, "ERROR"), " *"))
+		default:
+			continue
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
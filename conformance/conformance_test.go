@@ -0,0 +1,154 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// selfCheck is a small CP/M program that checks a few instructions and
+// prints its verdict in the style of TST8080, so that the runner can be
+// tested without the real exercisers.
+var selfCheck = []byte{
+	0x3e, 0x5a, // 0100 MVI A,5A
+	0xc6, 0xa6, // 0102 ADI A6: A=00 with Z and CY set
+	0xc2, 0x30, 0x01, // 0104 JNZ fail
+	0xd2, 0x30, 0x01, // 0107 JNC fail
+	0x06, 0x12, // 010A MVI B,12
+	0x0e, 0x34, // 010C MVI C,34
+	0xc5,       // 010E PUSH B
+	0xe1,       // 010F POP H
+	0x7c,       // 0110 MOV A,H
+	0xfe, 0x12, // 0111 CPI 12
+	0xc2, 0x30, 0x01, // 0113 JNZ fail
+	0x29,       // 0116 DAD H: HL=2468
+	0x7d,       // 0117 MOV A,L
+	0xfe, 0x68, // 0118 CPI 68
+	0xc2, 0x30, 0x01, // 011A JNZ fail
+	0x3e, 0x15, // 011D MVI A,15
+	0xc6, 0x27, // 011F ADI 27
+	0x27,       // 0121 DAA: A=42
+	0xfe, 0x42, // 0122 CPI 42
+	0xc2, 0x30, 0x01, // 0124 JNZ fail
+	0x11, 0x39, 0x01, // 0127 LXI D,ok
+	0x0e, 0x09, // 012A MVI C,9
+	0xcd, 0x05, 0x00, // 012C CALL BDOS
+	0xc9,             // 012F RET
+	0x11, 0x4e, 0x01, // 0130 fail: LXI D,failed
+	0x0e, 0x09, // 0133 MVI C,9
+	0xcd, 0x05, 0x00, // 0135 CALL BDOS
+	0xc9, // 0138 RET
+	// 0139 ok, 014E failed
+	'C', 'P', 'U', ' ', 'I', 'S', ' ', 'O', 'P', 'E', 'R', 'A', 'T', 'I', 'O', 'N', 'A', 'L', '\r', '\n', '$',
+	'C', 'P', 'U', ' ', 'H', 'A', 'S', ' ', 'F', 'A', 'I', 'L', 'E', 'D', '\r', '\n', '$',
+}
+
+func TestSelfCheck(t *testing.T) {
+	program, _ := Lookup("tst8080.com")
+	r, err := program.Run(selfCheck, nil, DefaultMaxCycles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed {
+		t.Fatalf("self check failed: %s\noutput: %q", r.Reason, r.Output)
+	}
+	if r.Instructions != 25 {
+		t.Errorf("ran %d instructions, want 25", r.Instructions)
+	}
+}
+
+func TestSelfCheckFailure(t *testing.T) {
+	code := append([]byte(nil), selfCheck...)
+	code[0x23] = 0x43 // CPI 43 after the DAA
+	program, _ := Lookup("TST8080.COM")
+	r, err := program.Run(code, nil, DefaultMaxCycles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Passed {
+		t.Fatalf("modified self check passed; output: %q", r.Output)
+	}
+	if !strings.Contains(r.Reason, "CPU HAS FAILED") {
+		t.Errorf("reason %q does not mention the failure message", r.Reason)
+	}
+}
+
+func TestRunaway(t *testing.T) {
+	loop := []byte{0xc3, 0x00, 0x01} // JMP 0100
+	r, err := Program{Name: "LOOP.COM", Pass: "OK"}.Run(loop, nil, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Passed || r.Err == nil {
+		t.Errorf("endless loop passed or stopped without an error: %+v", r)
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	output := "8080 instruction exerciser\r\n" +
+		"dad <b,d,h,sp>................  PASSED! crc is:14474ba6\r\n" +
+		"aluop nn......................  ERROR **** crc expected:9e922f9e found:1a2b3c4d\r\n" +
+		"Tests complete\r\n"
+	groups := parseGroups(output)
+	want := []Group{
+		{Name: "dad <b,d,h,sp>", Passed: true, Detail: "crc is:14474ba6"},
+		{Name: "aluop nn", Passed: false, Detail: "crc expected:9e922f9e found:1a2b3c4d"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d: %+v", len(groups), len(want), groups)
+	}
+	for i := range want {
+		if groups[i] != want[i] {
+			t.Errorf("group %d = %+v, want %+v", i, groups[i], want[i])
+		}
+	}
+}
+
+// findProgram returns the path of name in testdata, in any case.
+func findProgram(name string) (string, bool) {
+	entries, err := os.ReadDir("testdata")
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return filepath.Join("testdata", entry.Name()), true
+		}
+	}
+	return "", false
+}
+
+// TestExercisers runs each known exerciser found in testdata. They are not
+// distributed with the emulator; copy them there to run them.
+func TestExercisers(t *testing.T) {
+	for _, program := range Programs {
+		program := program
+		t.Run(program.Name, func(t *testing.T) {
+			path, ok := findProgram(program.Name)
+			if !ok {
+				t.Skipf("%s not found in testdata", program.Name)
+			}
+			if program.Long && testing.Short() {
+				t.Skipf("%s takes minutes; skipped in short mode", program.Name)
+			}
+			code, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := program.Run(code, nil, DefaultMaxCycles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("%d instructions, %d cycles, %v", r.Instructions, r.Cycles, r.Elapsed)
+			for _, g := range r.Groups {
+				if !g.Passed {
+					t.Errorf("group %s: %s", g.Name, g.Detail)
+				}
+			}
+			if !r.Passed {
+				t.Errorf("%s\noutput:\n%s", r.Reason, r.Output)
+			}
+		})
+	}
+}
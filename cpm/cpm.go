@@ -0,0 +1,268 @@
+// Package cpm runs CP/M .COM programs on a cpu8080 core. It provides just
+// enough of the BDOS for console I/O, which is all the usual 8080 test
+// programs need.
+package cpm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+)
+
+const (
+	// TPA is the start of the transient program area, where .COM programs
+	// are loaded and started.
+	TPA = 0x0100
+	// BDOS is the address programs call for operating system services.
+	BDOS = 0x0005
+	// BDOSBase is the top of the memory available to programs. The word at
+	// 0x0006 points here, and programs commonly put their stack below it.
+	BDOSBase = 0xfe00
+
+	// MaxProgramSize is the largest program that fits in the TPA.
+	MaxProgramSize = BDOSBase - TPA
+
+	// bdosCycles is the time charged for a BDOS call: that of the RET that
+	// returns from it.
+	bdosCycles = 10
+)
+
+var (
+	// ErrWarmBoot is returned by Step when the program exits by jumping to
+	// 0x0000 or calling BDOS function 0. It is the normal way for a CP/M
+	// program to finish.
+	ErrWarmBoot = errors.New("cpm: program exited (warm boot)")
+
+	// ErrUnsupported matches the error for a BDOS function this package
+	// does not provide.
+	ErrUnsupported = errors.New("cpm: unsupported BDOS function")
+
+	// ErrUnterminated matches the error for BDOS function 9 when no '$'
+	// ends the string anywhere in memory.
+	ErrUnterminated = errors.New("cpm: string has no $ terminator")
+)
+
+// BDOSError reports a BDOS call that could not be carried out.
+type BDOSError struct {
+	Err      error // ErrUnsupported, ErrUnterminated or an I/O error
+	Function uint8
+	Caller   uint16 // return address of the call
+}
+
+func (e *BDOSError) Error() string {
+	return fmt.Sprintf("%v %d called from %04X", e.Err, e.Function, e.Caller)
+}
+
+func (e *BDOSError) Unwrap() error {
+	return e.Err
+}
+
+// Machine is a CPU with 64 KiB of RAM laid out for CP/M, with the BDOS
+// entry point trapped and handled in Go.
+type Machine struct {
+	CPU *cpu8080.State8080
+	RAM cpu8080.RAM
+
+	// Out receives console output; nil discards it.
+	Out io.Writer
+	// In supplies console input; nil reads as end of file, which console
+	// functions see as Ctrl-Z.
+	In io.Reader
+
+	in *bufio.Reader
+}
+
+// New builds a machine with program loaded at TPA and ready to run. The
+// stack starts just below BDOSBase with 0x0000 pushed, so a program that
+// returns from its entry point exits with a warm boot.
+func New(program []byte) (*Machine, error) {
+	if len(program) > MaxProgramSize {
+		return nil, fmt.Errorf("cpm: program is %d bytes, want at most %d", len(program), MaxProgramSize)
+	}
+	ram := cpu8080.NewRAM(0x10000)
+	m := &Machine{CPU: cpu8080.NewState8080WithBus(ram), RAM: ram}
+
+	// 0x0000 jumps to the warm boot entry and 0x0005 to the BDOS, as on
+	// a real system; both are trapped before the jumps execute. The BDOS
+	// entry itself is a RET in case a program jumps to it directly.
+	copy(ram[0x0000:], []byte{0xc3, 0x03, BDOSBase >> 8})
+	copy(ram[BDOS:], []byte{0xc3, BDOSBase & 0xff, BDOSBase >> 8})
+	ram[BDOSBase] = 0xc9
+	copy(ram[BDOSBase+3:], []byte{0xc3, 0x00, 0x00})
+
+	copy(ram[TPA:], program)
+	m.CPU.PC = TPA
+	m.CPU.SP = BDOSBase - 2
+	return m, nil
+}
+
+// Step executes one instruction, or a whole BDOS call when the program has
+// jumped to BDOS. It returns ErrWarmBoot once the program reaches 0x0000.
+func (m *Machine) Step() (int, error) {
+	cpu := m.CPU
+	if cpu.Halted || cpu.InterruptPending() {
+		return cpu.Step()
+	}
+	switch cpu.PC {
+	case 0x0000:
+		return 0, ErrWarmBoot
+	case BDOS:
+		err := m.bdos()
+		// return to the caller as the RET at the end of the BDOS would
+		cpu.PC = uint16(m.RAM[cpu.SP+1])<<8 | uint16(m.RAM[cpu.SP])
+		cpu.SP += 2
+		cpu.Cycles += bdosCycles
+		return bdosCycles, err
+	}
+	return cpu.Step()
+}
+
+// Run steps the machine until at least cycles T-states have elapsed or an
+// error occurs, and returns the number of T-states executed.
+func (m *Machine) Run(cycles int) (int, error) {
+	var elapsed int
+	for elapsed < cycles {
+		n, err := m.Step()
+		elapsed += n
+		if err != nil {
+			return elapsed, err
+		}
+	}
+	return elapsed, nil
+}
+
+// bdos carries out the BDOS function in C with the argument in E or DE. The
+// result goes in A and L, or HL and BA for 16-bit results.
+func (m *Machine) bdos() error {
+	cpu := m.CPU
+	var result uint16
+	var err error
+	switch cpu.C {
+	case 0:
+		// system reset
+		return ErrWarmBoot
+	case 1:
+		// console input, echoed
+		var c byte
+		if c, err = m.readChar(); err == nil {
+			err = m.write(c)
+		}
+		result = uint16(c)
+	case 2:
+		// console output
+		err = m.write(cpu.E)
+	case 6:
+		// direct console I/O: input without echo when E is 0xff
+		if cpu.E == 0xff {
+			var c byte
+			c, err = m.readChar()
+			result = uint16(c)
+		} else {
+			err = m.write(cpu.E)
+		}
+	case 9:
+		// print string ending in $
+		err = m.printString(cpu.DE())
+	case 10:
+		// read console buffer: DE points at the maximum length, which is
+		// followed by the count of characters read and the characters
+		err = m.readLine(cpu.DE())
+	case 11:
+		// console status: ready when input is waiting in the buffer
+		if m.in != nil && m.in.Buffered() > 0 {
+			result = 0xff
+		}
+	case 12:
+		// version: CP/M 2.2
+		result = 0x0022
+	default:
+		err = ErrUnsupported
+	}
+	if err != nil {
+		caller := uint16(m.RAM[cpu.SP+1])<<8 | uint16(m.RAM[cpu.SP])
+		return &BDOSError{Err: err, Function: cpu.C, Caller: caller}
+	}
+	cpu.SetHL(result)
+	cpu.A = uint8(result)
+	cpu.B = uint8(result >> 8)
+	return nil
+}
+
+// printString writes the string at addr up to the '$' that ends it. The
+// whole of memory is searched for the '$' before anything is written.
+func (m *Machine) printString(addr uint16) error {
+	n := bytes.IndexByte(m.RAM[addr:], '$')
+	if n < 0 {
+		// the string wraps around the top of memory
+		wrapped := bytes.IndexByte(m.RAM[:addr], '$')
+		if wrapped < 0 {
+			return ErrUnterminated
+		}
+		n = len(m.RAM) - int(addr) + wrapped
+	}
+	for i := 0; i < n; i++ {
+		if err := m.write(m.RAM[addr+uint16(i)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Machine) write(c byte) error {
+	if m.Out == nil {
+		return nil
+	}
+	_, err := m.Out.Write([]byte{c})
+	return err
+}
+
+// readChar reads one character of console input, turning newlines into
+// carriage returns as a terminal would send them.
+func (m *Machine) readChar() (byte, error) {
+	if m.In == nil {
+		return 0x1a, nil
+	}
+	if m.in == nil {
+		m.in = bufio.NewReader(m.In)
+	}
+	c, err := m.in.ReadByte()
+	switch {
+	case errors.Is(err, io.EOF):
+		return 0x1a, nil
+	case err != nil:
+		return 0, err
+	case c == '\n':
+		return '\r', nil
+	}
+	return c, nil
+}
+
+// readLine fills the console buffer at addr with one line of input, echoing
+// it.
+func (m *Machine) readLine(addr uint16) error {
+	max := int(m.RAM[addr])
+	n := 0
+	for n < max {
+		c, err := m.readChar()
+		if err != nil {
+			return err
+		}
+		if c == '\r' || c == 0x1a {
+			break
+		}
+		m.RAM[addr+2+uint16(n)] = c
+		n++
+		if err := m.write(c); err != nil {
+			return err
+		}
+	}
+	m.RAM[addr+1] = uint8(n)
+	if err := m.write('\r'); err != nil {
+		return err
+	}
+	return m.write('\n')
+}
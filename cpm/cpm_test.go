@@ -0,0 +1,145 @@
+package cpm
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// bdos returns the code for a BDOS call of function c with DE set to de.
+func bdos(c uint8, de uint16) []byte {
+	return []byte{
+		0x11, uint8(de), uint8(de >> 8), // LXI D,de
+		0x0e, c, // MVI C,c
+		0xcd, 0x05, 0x00, // CALL BDOS
+	}
+}
+
+// program joins pieces of code into a program.
+func program(pieces ...[]byte) []byte {
+	return bytes.Join(pieces, nil)
+}
+
+var ret = []byte{0xc9}
+
+func TestBDOS(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    []byte
+		data    map[uint16]string // strings to place in memory
+		input   string
+		noInput bool // leave In nil
+		output  string
+		a       uint8
+		err     error
+	}{
+		{name: "2 console output", code: program(bdos(2, 'A'), ret), output: "A", a: 0x00},
+		{name: "9 print string", code: program(bdos(9, 0x0200), ret), data: map[uint16]string{0x0200: "HELLO\r\n$IGNORED"}, output: "HELLO\r\n"},
+		{name: "9 empty string", code: program(bdos(9, 0x0200), ret), data: map[uint16]string{0x0200: "$"}},
+		{name: "9 wraps around memory", code: program(bdos(9, 0xffff), ret), data: map[uint16]string{0xffff: "X", 0x0003: "$"},
+			output: "X\xc3\x03\xfe"},
+		{name: "1 console input", code: program(bdos(1, 0), ret), input: "x", output: "x", a: 'x'},
+		{name: "1 newline as carriage return", code: program(bdos(1, 0), ret), input: "\n", output: "\r", a: '\r'},
+		{name: "1 end of input", code: program(bdos(1, 0), ret), output: "\x1a", a: 0x1a},
+		{name: "1 without a reader", code: program(bdos(1, 0), ret), noInput: true, output: "\x1a", a: 0x1a},
+		{name: "6 direct input", code: program(bdos(6, 0xff), ret), input: "q", a: 'q'},
+		{name: "6 direct output", code: program(bdos(6, 'Z'), ret), output: "Z"},
+		{name: "11 status idle", code: program(bdos(11, 0), ret), input: "a", a: 0x00},
+		{name: "11 status after read", code: program(bdos(1, 0), bdos(11, 0), ret), input: "ab", output: "a", a: 0xff},
+		{name: "12 version", code: program(bdos(12, 0), ret), a: 0x22},
+		{name: "0 system reset", code: program(bdos(0, 0), []byte{0x76})},
+		{name: "jump to 0000", code: []byte{0xc3, 0x00, 0x00}},
+		{name: "unsupported function", code: program(bdos(15, 0), ret), err: ErrUnsupported},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(tc.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for addr, s := range tc.data {
+				copy(m.RAM[addr:], s)
+			}
+			var out bytes.Buffer
+			m.Out = &out
+			if !tc.noInput {
+				m.In = strings.NewReader(tc.input)
+			}
+
+			_, err = m.Run(1000)
+			want := tc.err
+			if want == nil {
+				want = ErrWarmBoot
+			}
+			if !errors.Is(err, want) {
+				t.Fatalf("Run: %v, want %v", err, want)
+			}
+			if out.String() != tc.output {
+				t.Errorf("output %q, want %q", out.String(), tc.output)
+			}
+			if tc.err == nil && m.CPU.A != tc.a {
+				t.Errorf("A = %02X, want %02X", m.CPU.A, tc.a)
+			}
+		})
+	}
+}
+
+func TestReadConsoleBuffer(t *testing.T) {
+	m, err := New(program(bdos(10, 0x0200), bdos(10, 0x0210), ret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.RAM[0x0200] = 10
+	m.RAM[0x0210] = 3
+	var out bytes.Buffer
+	m.Out = &out
+	m.In = strings.NewReader("hello\nabcdef\n")
+	if _, err := m.Run(1000); !errors.Is(err, ErrWarmBoot) {
+		t.Fatalf("Run: %v, want ErrWarmBoot", err)
+	}
+	if n, line := m.RAM[0x0201], string(m.RAM[0x0202:0x0207]); n != 5 || line != "hello" {
+		t.Errorf("first buffer holds %d %q, want 5 \"hello\"", n, line)
+	}
+	if n, line := m.RAM[0x0211], string(m.RAM[0x0212:0x0215]); n != 3 || line != "abc" {
+		t.Errorf("second buffer holds %d %q, want 3 \"abc\" (its maximum)", n, line)
+	}
+	if want := "hello\r\nabc\r\n"; out.String() != want {
+		t.Errorf("echo %q, want %q", out.String(), want)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	code := program(bdos(9, 0x2000), ret)
+	if bytes.IndexByte(code, '$') >= 0 {
+		t.Fatal("test program contains a $")
+	}
+	m, err := New(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	m.Out = &out
+
+	_, err = m.Run(1000)
+	var bdosErr *BDOSError
+	if !errors.As(err, &bdosErr) || !errors.Is(err, ErrUnterminated) {
+		t.Fatalf("Run: %v, want a BDOSError for ErrUnterminated", err)
+	}
+	if bdosErr.Function != 9 || bdosErr.Caller != 0x0108 {
+		t.Errorf("error for function %d called from %04X, want 9 from 0108", bdosErr.Function, bdosErr.Caller)
+	}
+	if out.Len() != 0 {
+		t.Errorf("printed %d bytes of an unterminated string", out.Len())
+	}
+}
+
+func TestReturnFromProgram(t *testing.T) {
+	m, err := New(ret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Run(1000); !errors.Is(err, ErrWarmBoot) {
+		t.Fatalf("Run: %v, want ErrWarmBoot", err)
+	}
+}
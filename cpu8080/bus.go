@@ -0,0 +1,131 @@
+package cpu8080
+
+// Bus is the CPU's view of the 16-bit address space. Every memory access made
+// by an instruction goes through it.
+type Bus interface {
+	Read(address uint16) uint8
+	Write(address uint16, value uint8)
+}
+
+// RAM is flat, fully writable memory. Addresses past the end wrap around, so
+// a RAM shorter than 64 KiB mirrors itself across the address space.
+type RAM []byte
+
+// NewRAM returns size bytes of zeroed RAM.
+func NewRAM(size int) RAM {
+	return make(RAM, size)
+}
+
+func (r RAM) Read(address uint16) uint8 {
+	return r[int(address)%len(r)]
+}
+
+func (r RAM) Write(address uint16, value uint8) {
+	r[int(address)%len(r)] = value
+}
+
+// ROM is read-only memory. Writes are ignored.
+type ROM []byte
+
+func (r ROM) Read(address uint16) uint8 {
+	return r[int(address)%len(r)]
+}
+
+func (r ROM) Write(address uint16, value uint8) {}
+
+// region maps the addresses [start, start+size) onto a bus. The bus sees
+// addresses relative to start.
+type region struct {
+	start uint16
+	size  int
+	bus   Bus
+}
+
+func (r region) contains(address uint16) bool {
+	return address >= r.start && int(address-r.start) < r.size
+}
+
+// MemoryMap composes a machine's address space from regions of RAM, ROM,
+// mirrors and memory-mapped devices. Regions mapped later take precedence
+// over earlier ones. Addresses covered by no region are open bus: reads
+// return OpenBus and writes are ignored.
+type MemoryMap struct {
+	OpenBus uint8
+	regions []region
+}
+
+// NewMemoryMap returns an empty memory map whose open bus reads 0xff.
+func NewMemoryMap() *MemoryMap {
+	return &MemoryMap{OpenBus: 0xff}
+}
+
+// Map attaches bus to the size bytes starting at start. This is the hook for
+// memory-mapped devices: the bus receives addresses relative to start.
+func (m *MemoryMap) Map(start uint16, size int, bus Bus) {
+	m.regions = append(m.regions, region{start: start, size: size, bus: bus})
+}
+
+// MapRAM maps size bytes of new RAM at start and returns it.
+func (m *MemoryMap) MapRAM(start uint16, size int) RAM {
+	ram := NewRAM(size)
+	m.Map(start, size, ram)
+	return ram
+}
+
+// MapROM maps data read-only at start.
+func (m *MemoryMap) MapROM(start uint16, data []byte) {
+	m.Map(start, len(data), ROM(data))
+}
+
+// Mirror makes the size bytes starting at start repeat the targetSize bytes
+// starting at target.
+func (m *MemoryMap) Mirror(start uint16, size int, target uint16, targetSize int) {
+	m.Map(start, size, &mirror{m: m, target: target, size: targetSize})
+}
+
+// Unmap turns the size bytes starting at start into open bus, hiding any
+// regions mapped there before.
+func (m *MemoryMap) Unmap(start uint16, size int) {
+	m.Map(start, size, nil)
+}
+
+func (m *MemoryMap) find(address uint16) (Bus, uint16) {
+	for i := len(m.regions) - 1; i >= 0; i-- {
+		r := m.regions[i]
+		if r.contains(address) {
+			return r.bus, address - r.start
+		}
+	}
+	return nil, 0
+}
+
+func (m *MemoryMap) Read(address uint16) uint8 {
+	bus, offset := m.find(address)
+	if bus == nil {
+		return m.OpenBus
+	}
+	return bus.Read(offset)
+}
+
+func (m *MemoryMap) Write(address uint16, value uint8) {
+	bus, offset := m.find(address)
+	if bus == nil {
+		return
+	}
+	bus.Write(offset, value)
+}
+
+// mirror redirects accesses back into the memory map at another address.
+type mirror struct {
+	m      *MemoryMap
+	target uint16
+	size   int
+}
+
+func (mi *mirror) Read(offset uint16) uint8 {
+	return mi.m.Read(mi.target + uint16(int(offset)%mi.size))
+}
+
+func (mi *mirror) Write(offset uint16, value uint8) {
+	mi.m.Write(mi.target+uint16(int(offset)%mi.size), value)
+}
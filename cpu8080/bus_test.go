@@ -0,0 +1,72 @@
+package cpu8080
+
+import "testing"
+
+func TestMemoryMap(t *testing.T) {
+	m := NewMemoryMap()
+	m.MapROM(0x0000, []byte{0x11, 0x22, 0x33, 0x44})
+	ram := m.MapRAM(0x2000, 0x0400)
+	m.Mirror(0x2400, 0x0c00, 0x2000, 0x0400)
+	m.MapRAM(0x2100, 0x0010) // covers part of the RAM above
+	m.Unmap(0x2200, 0x0010)
+	m.MapRAM(0xff00, 0x0200) // runs past 0xffff
+
+	// Each step writes value if write is set, then reads addr and expects
+	// want. The steps run in order on the same map.
+	steps := []struct {
+		name  string
+		write bool
+		addr  uint16
+		value uint8
+		want  uint8
+	}{
+		{"ROM", false, 0x0002, 0, 0x33},
+		{"ROM ignores writes", true, 0x0002, 0x99, 0x33},
+		{"open bus", false, 0x1000, 0, 0xff},
+		{"open bus ignores writes", true, 0x1000, 0x00, 0xff},
+		{"open bus past ROM", false, 0x0004, 0, 0xff},
+		{"RAM", true, 0x2010, 0xab, 0xab},
+		{"mirror", false, 0x2410, 0, 0xab},
+		{"mirror wraps around its target", false, 0x2c10, 0, 0xab},
+		{"mirror writes through", true, 0x2820, 0xcd, 0xcd},
+		{"later region wins", true, 0x2105, 0x77, 0x77},
+		{"mirror sees later region", false, 0x2505, 0, 0x77},
+		{"unmapped hole", true, 0x2203, 0x42, 0xff},
+		{"hole in mirror", false, 0x2603, 0, 0xff},
+		{"region past 0xffff", true, 0xffff, 0x12, 0x12},
+		{"no wrap to 0x0000", false, 0x0000, 0, 0x11},
+	}
+	for _, step := range steps {
+		if step.write {
+			m.Write(step.addr, step.value)
+		}
+		if got := m.Read(step.addr); got != step.want {
+			t.Errorf("%s: read %04X = %02X, want %02X", step.name, step.addr, got, step.want)
+		}
+	}
+
+	if ram[0x020] != 0xcd {
+		t.Errorf("mirror write did not reach RAM: %02X", ram[0x020])
+	}
+	if ram[0x105] != 0 || ram[0x203] != 0 {
+		t.Errorf("covered RAM was written: %02X %02X", ram[0x105], ram[0x203])
+	}
+
+	m.OpenBus = 0x00
+	if got := m.Read(0x1000); got != 0x00 {
+		t.Errorf("open bus with OpenBus 00 reads %02X", got)
+	}
+}
+
+func TestRAMWraps(t *testing.T) {
+	ram := NewRAM(0x0400)
+	ram.Write(0x0410, 0x5a)
+	if ram[0x0010] != 0x5a || ram.Read(0x4010) != 0x5a {
+		t.Errorf("RAM shorter than 64 KiB does not mirror itself")
+	}
+	rom := ROM{0x01, 0x02}
+	rom.Write(0x0001, 0xff)
+	if rom.Read(0x0003) != 0x02 {
+		t.Errorf("ROM = %v after write, want it unchanged and mirrored", rom)
+	}
+}
@@ -0,0 +1,125 @@
+package cpu8080
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mnemonics holds the assembly form of every opcode. D8 stands for an
+// immediate byte, D16 for an immediate word and adr for an address. A
+// leading * marks an undocumented opcode.
+var mnemonics = [256]string{
+	// 0x00
+	"NOP", "LXI B,D16", "STAX B", "INX B",
+	"INR B", "DCR B", "MVI B,D8", "RLC",
+	"*NOP", "DAD B", "LDAX B", "DCX B",
+	"INR C", "DCR C", "MVI C,D8", "RRC",
+	// 0x10
+	"*NOP", "LXI D,D16", "STAX D", "INX D",
+	"INR D", "DCR D", "MVI D,D8", "RAL",
+	"*NOP", "DAD D", "LDAX D", "DCX D",
+	"INR E", "DCR E", "MVI E,D8", "RAR",
+	// 0x20
+	"*NOP", "LXI H,D16", "SHLD adr", "INX H",
+	"INR H", "DCR H", "MVI H,D8", "DAA",
+	"*NOP", "DAD H", "LHLD adr", "DCX H",
+	"INR L", "DCR L", "MVI L,D8", "CMA",
+	// 0x30
+	"*NOP", "LXI SP,D16", "STA adr", "INX SP",
+	"INR M", "DCR M", "MVI M,D8", "STC",
+	"*NOP", "DAD SP", "LDA adr", "DCX SP",
+	"INR A", "DCR A", "MVI A,D8", "CMC",
+	// 0x40
+	"MOV B,B", "MOV B,C", "MOV B,D", "MOV B,E",
+	"MOV B,H", "MOV B,L", "MOV B,M", "MOV B,A",
+	"MOV C,B", "MOV C,C", "MOV C,D", "MOV C,E",
+	"MOV C,H", "MOV C,L", "MOV C,M", "MOV C,A",
+	// 0x50
+	"MOV D,B", "MOV D,C", "MOV D,D", "MOV D,E",
+	"MOV D,H", "MOV D,L", "MOV D,M", "MOV D,A",
+	"MOV E,B", "MOV E,C", "MOV E,D", "MOV E,E",
+	"MOV E,H", "MOV E,L", "MOV E,M", "MOV E,A",
+	// 0x60
+	"MOV H,B", "MOV H,C", "MOV H,D", "MOV H,E",
+	"MOV H,H", "MOV H,L", "MOV H,M", "MOV H,A",
+	"MOV L,B", "MOV L,C", "MOV L,D", "MOV L,E",
+	"MOV L,H", "MOV L,L", "MOV L,M", "MOV L,A",
+	// 0x70
+	"MOV M,B", "MOV M,C", "MOV M,D", "MOV M,E",
+	"MOV M,H", "MOV M,L", "HLT", "MOV M,A",
+	"MOV A,B", "MOV A,C", "MOV A,D", "MOV A,E",
+	"MOV A,H", "MOV A,L", "MOV A,M", "MOV A,A",
+	// 0x80
+	"ADD B", "ADD C", "ADD D", "ADD E",
+	"ADD H", "ADD L", "ADD M", "ADD A",
+	"ADC B", "ADC C", "ADC D", "ADC E",
+	"ADC H", "ADC L", "ADC M", "ADC A",
+	// 0x90
+	"SUB B", "SUB C", "SUB D", "SUB E",
+	"SUB H", "SUB L", "SUB M", "SUB A",
+	"SBB B", "SBB C", "SBB D", "SBB E",
+	"SBB H", "SBB L", "SBB M", "SBB A",
+	// 0xa0
+	"ANA B", "ANA C", "ANA D", "ANA E",
+	"ANA H", "ANA L", "ANA M", "ANA A",
+	"XRA B", "XRA C", "XRA D", "XRA E",
+	"XRA H", "XRA L", "XRA M", "XRA A",
+	// 0xb0
+	"ORA B", "ORA C", "ORA D", "ORA E",
+	"ORA H", "ORA L", "ORA M", "ORA A",
+	"CMP B", "CMP C", "CMP D", "CMP E",
+	"CMP H", "CMP L", "CMP M", "CMP A",
+	// 0xc0
+	"RNZ", "POP B", "JNZ adr", "JMP adr",
+	"CNZ adr", "PUSH B", "ADI D8", "RST 0",
+	"RZ", "RET", "JZ adr", "*JMP adr",
+	"CZ adr", "CALL adr", "ACI D8", "RST 1",
+	// 0xd0
+	"RNC", "POP D", "JNC adr", "OUT D8",
+	"CNC adr", "PUSH D", "SUI D8", "RST 2",
+	"RC", "*RET", "JC adr", "IN D8",
+	"CC adr", "*CALL adr", "SBI D8", "RST 3",
+	// 0xe0
+	"RPO", "POP H", "JPO adr", "XTHL",
+	"CPO adr", "PUSH H", "ANI D8", "RST 4",
+	"RPE", "PCHL", "JPE adr", "XCHG",
+	"CPE adr", "*CALL adr", "XRI D8", "RST 5",
+	// 0xf0
+	"RP", "POP PSW", "JP adr", "DI",
+	"CP adr", "PUSH PSW", "ORI D8", "RST 6",
+	"RM", "SPHL", "JM adr", "EI",
+	"CM adr", "*CALL adr", "CPI D8", "RST 7",
+}
+
+// InstructionLength returns the size in bytes of the instruction starting
+// with opcode: 1, 2 or 3.
+func InstructionLength(opcode uint8) int {
+	m := mnemonics[opcode]
+	switch {
+	case strings.HasSuffix(m, "D16"), strings.HasSuffix(m, "adr"):
+		return 3
+	case strings.HasSuffix(m, "D8"):
+		return 2
+	}
+	return 1
+}
+
+// Disassemble decodes the instruction at addr on bus and returns it in
+// assembly form, such as "JMP $18D4", along with its length in bytes.
+func Disassemble(bus Bus, addr uint16) (string, int) {
+	opcode := bus.Read(addr)
+	m := mnemonics[opcode]
+	n := InstructionLength(opcode)
+	switch {
+	case n == 3:
+		word := uint16(bus.Read(addr+2))<<8 | uint16(bus.Read(addr+1))
+		prefix := strings.TrimSuffix(strings.TrimSuffix(m, "D16"), "adr")
+		m = fmt.Sprintf("%s$%04X", prefix, word)
+	case opcode == 0xd3 || opcode == 0xdb:
+		// IN and OUT take a port number rather than an immediate value
+		m = fmt.Sprintf("%s$%02X", strings.TrimSuffix(m, "D8"), bus.Read(addr+1))
+	case n == 2:
+		m = fmt.Sprintf("%s#$%02X", strings.TrimSuffix(m, "D8"), bus.Read(addr+1))
+	}
+	return m, n
+}
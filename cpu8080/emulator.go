@@ -0,0 +1,1852 @@
+// Package cpu8080 emulates the Intel 8080 CPU.
+//
+// A State8080 holds the registers and flags, reaches memory through a Bus and
+// reaches I/O ports through a Device.
+// Machines drive it by calling Step or Run and by raising interrupts with
+// Interrupt.
+package cpu8080
+
+// haltCycles is the number of T-states a halted CPU idles for on each Step
+// while it waits for an interrupt.
+const haltCycles = 4
+
+// ConditionCodes holds the 8080 condition flags.
+type ConditionCodes struct {
+	Z   bool  // Zero
+	S   bool  // Sign
+	P   bool  // Parity
+	CY  bool  // Carry
+	AC  bool  // Auxiliary Carry
+	Pad uint8 // Padding
+}
+
+// State8080 is the complete state of an 8080 CPU.
+type State8080 struct {
+	A         uint8
+	B         uint8
+	C         uint8
+	D         uint8
+	E         uint8
+	H         uint8
+	L         uint8
+	SP        uint16
+	PC        uint16
+	Bus       Bus
+	IO        Device // target of IN and OUT; usually a *Ports
+	Cc        ConditionCodes
+	IntEnable bool
+	Halted    bool   // HLT executed; waiting for an interrupt
+	Cycles    uint64 // T-states executed since reset
+
+	// TrapUndocumented makes undocumented opcodes fail with ErrIllegalOpcode
+	// instead of executing as the instructions they alias.
+	TrapUndocumented bool
+
+	// Trace, if set, is called by Step before each instruction is fetched
+	// from PC. Debuggers and tracers use it to watch execution.
+	Trace func(state *State8080)
+
+	interruptPending  bool     // an interrupt has been requested but not acknowledged
+	interruptOpcode   uint8    // instruction placed on the data bus by the interrupt
+	interruptOperands [2]uint8 // its operand bytes, if it has any
+	eiDelay           bool     // EI was the last instruction; interrupts wait one more
+}
+
+// NewState8080 returns a CPU with 64 KiB of flat RAM and rom loaded at 0x0000.
+func NewState8080(rom []byte) *State8080 {
+	ram := NewRAM(0x10000)
+	copy(ram, rom)
+	return NewState8080WithBus(ram)
+}
+
+// NewState8080WithBus returns a CPU that accesses memory through bus.
+func NewState8080WithBus(bus Bus) *State8080 {
+	state := State8080{}
+	state.Bus = bus
+	state.IO = NewPorts()
+	state.SP = 0x0000
+	state.PC = 0x0000
+	state.IntEnable = false
+	return &state
+}
+
+// Reset puts the CPU back into its power-on state. Memory is left intact.
+func (state *State8080) Reset() {
+	state.A, state.B, state.C, state.D, state.E, state.H, state.L = 0, 0, 0, 0, 0, 0, 0
+	state.SP = 0x0000
+	state.PC = 0x0000
+	state.Cc = ConditionCodes{}
+	state.IntEnable = false
+	state.Halted = false
+	state.Cycles = 0
+	state.interruptPending = false
+	state.eiDelay = false
+}
+
+// Load writes data to memory starting at address. The writes go through the
+// bus, so they are ignored where the bus maps ROM.
+func (state *State8080) Load(address uint16, data []byte) {
+	for i, b := range data {
+		state.write(address+uint16(i), b)
+	}
+}
+
+func (state *State8080) read(address uint16) uint8 {
+	return state.Bus.Read(address)
+}
+
+func (state *State8080) write(address uint16, value uint8) {
+	state.Bus.Write(address, value)
+}
+
+func (state *State8080) in(port uint8) uint8 {
+	if state.IO == nil {
+		return 0
+	}
+	return state.IO.In(port)
+}
+
+func (state *State8080) out(port uint8, value uint8) {
+	if state.IO != nil {
+		state.IO.Out(port, value)
+	}
+}
+
+// Step executes a single instruction and returns the number of T-states it
+// took. A pending interrupt is acknowledged instead of fetching from PC. While
+// halted, Step idles until an interrupt arrives, or returns ErrHalted if
+// interrupts are disabled. Errors from Step are *OpcodeError or *HaltError.
+func (state *State8080) Step() (int, error) {
+	var cycles int
+	var err error
+	if state.interruptReady() {
+		state.Halted = false
+		cycles, err = state.acknowledgeInterrupt()
+	} else if state.Halted {
+		state.eiDelay = false
+		cycles = haltCycles
+		if !state.IntEnable {
+			err = state.haltError()
+		}
+	} else {
+		state.eiDelay = false
+		if state.Trace != nil {
+			state.Trace(state)
+		}
+		cycles, err = Emulate8080Op(state)
+	}
+	state.Cycles += uint64(cycles)
+	return cycles, err
+}
+
+// Run executes instructions until at least cycles T-states have elapsed or an
+// error occurs, and returns the number of T-states actually executed.
+func (state *State8080) Run(cycles int) (int, error) {
+	var elapsed int
+	for elapsed < cycles {
+		n, err := state.Step()
+		elapsed += n
+		if err != nil {
+			return elapsed, err
+		}
+	}
+	return elapsed, nil
+}
+
+// UnimplementedInstruction returns the error for an opcode the emulator
+// cannot execute. PC is left pointing at the opcode.
+func UnimplementedInstruction(state *State8080, opcode uint8) error {
+	return &OpcodeError{
+		Err:       ErrUnimplemented,
+		Opcode:    opcode,
+		PC:        state.PC,
+		Registers: state.Registers(),
+	}
+}
+
+// Emulate8080Op executes the instruction at PC and returns the number of
+// T-states it took.
+func Emulate8080Op(state *State8080) (int, error) {
+	return emulateOpcode(state, state.read(state.PC))
+}
+
+// emulateOpcode executes opcode as though it had been fetched from PC. Any
+// operand bytes are still read from memory following PC.
+func emulateOpcode(state *State8080, opcode uint8) (int, error) {
+	cycles := int(cycles8080[opcode])
+	if state.TrapUndocumented && isUndocumented(opcode) {
+		return 0, &OpcodeError{
+			Err:       ErrIllegalOpcode,
+			Opcode:    opcode,
+			PC:        state.PC,
+			Registers: state.Registers(),
+		}
+	}
+	switch opcode {
+
+	// NOP
+	case 0x00:
+		state.PC++
+		break
+
+	// LXI B, D16
+	case 0x01:
+		state.SetBC(state.operand())
+		state.PC += 3
+		break
+
+	// STAX B
+	case 0x02:
+		state.write(state.BC(), state.A)
+		state.PC++
+		break
+
+	// INX B
+	case 0x03:
+		state.SetBC(state.BC() + 1)
+		state.PC++
+		break
+
+	// INR B
+	case 0x04:
+		state.B = state.inr(state.B)
+		state.PC++
+		break
+
+	// DCR B
+	case 0x05:
+		state.B = state.dcr(state.B)
+		state.PC++
+		break
+
+	// MVI B, D8
+	case 0x06:
+		state.B = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// RLC
+	case 0x07:
+		x := state.A
+		state.A = (x << 1) | (x >> 7)
+		state.Cc.CY = (x & 0x80) != 0
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x08:
+		state.PC++
+		break
+
+	// DAD B
+	case 0x09:
+		state.dad(state.BC())
+		state.PC++
+		break
+
+	// LDAX B
+	case 0x0a:
+		state.A = state.read(state.BC())
+		state.PC++
+		break
+
+	// DCX B
+	case 0x0b:
+		state.SetBC(state.BC() - 1)
+		state.PC++
+		break
+
+	// INR C
+	case 0x0c:
+		state.C = state.inr(state.C)
+		state.PC++
+		break
+
+	// DCR C
+	case 0x0d:
+		state.C = state.dcr(state.C)
+		state.PC++
+		break
+
+	// MVI C, D8
+	case 0x0e:
+		state.C = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// RRC
+	case 0x0f:
+		x := state.A
+		state.A = (x >> 1) | (x << 7)
+		state.Cc.CY = (x & 0x01) != 0
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x10:
+		state.PC++
+		break
+
+	// LXI D, D16
+	case 0x11:
+		state.SetDE(state.operand())
+		state.PC += 3
+		break
+
+	// STAX D
+	case 0x12:
+		state.write(state.DE(), state.A)
+		state.PC++
+		break
+
+	// INX D
+	case 0x13:
+		state.SetDE(state.DE() + 1)
+		state.PC++
+		break
+
+	// INR D
+	case 0x14:
+		state.D = state.inr(state.D)
+		state.PC++
+		break
+
+	// DCR D
+	case 0x15:
+		state.D = state.dcr(state.D)
+		state.PC++
+		break
+
+	// MVI D, D8
+	case 0x16:
+		state.D = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// RAL
+	case 0x17:
+		x := state.A
+		state.A = x << 1
+		if state.Cc.CY {
+			state.A |= 0x01
+		}
+		state.Cc.CY = (x & 0x80) != 0
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x18:
+		state.PC++
+		break
+
+	// DAD D
+	case 0x19:
+		state.dad(state.DE())
+		state.PC++
+		break
+
+	// LDAX D
+	case 0x1a:
+		state.A = state.read(state.DE())
+		state.PC++
+		break
+
+	// DCX D
+	case 0x1b:
+		state.SetDE(state.DE() - 1)
+		state.PC++
+		break
+
+	// INR E
+	case 0x1c:
+		state.E = state.inr(state.E)
+		state.PC++
+		break
+
+	// DCR E
+	case 0x1d:
+		state.E = state.dcr(state.E)
+		state.PC++
+		break
+
+	// MVI E, D8
+	case 0x1e:
+		state.E = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// RAR
+	case 0x1f:
+		x := state.A
+		state.A = x >> 1
+		if state.Cc.CY {
+			state.A |= 0x80
+		}
+		state.Cc.CY = (x & 0x01) != 0
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x20:
+		state.PC++
+		break
+
+	// LXI H, D16
+	case 0x21:
+		state.SetHL(state.operand())
+		state.PC += 3
+		break
+
+	// SHLD adr
+	case 0x22:
+		adr := state.operand()
+		state.write(adr, state.L)
+		state.write(adr+1, state.H)
+		state.PC += 3
+		break
+
+	// INX H
+	case 0x23:
+		state.SetHL(state.HL() + 1)
+		state.PC++
+		break
+
+	// INR H
+	case 0x24:
+		state.H = state.inr(state.H)
+		state.PC++
+		break
+
+	// DCR H
+	case 0x25:
+		state.H = state.dcr(state.H)
+		state.PC++
+		break
+
+	// MVI H, D8
+	case 0x26:
+		state.H = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// DAA
+	case 0x27:
+		state.daa()
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x28:
+		state.PC++
+		break
+
+	// DAD H
+	case 0x29:
+		state.dad(state.HL())
+		state.PC++
+		break
+
+	// LHLD adr
+	case 0x2a:
+		adr := state.operand()
+		state.SetHL(state.read16(adr))
+		state.PC += 3
+		break
+
+	// DCX H
+	case 0x2b:
+		state.SetHL(state.HL() - 1)
+		state.PC++
+		break
+
+	// INR L
+	case 0x2c:
+		state.L = state.inr(state.L)
+		state.PC++
+		break
+
+	// DCR L
+	case 0x2d:
+		state.L = state.dcr(state.L)
+		state.PC++
+		break
+
+	// MVI L, D8
+	case 0x2e:
+		state.L = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// CMA
+	case 0x2f:
+		state.A = ^state.A
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x30:
+		state.PC++
+		break
+
+	// LXI SP, D16
+	case 0x31:
+		state.SP = state.operand()
+		state.PC += 3
+		break
+
+	// STA adr
+	case 0x32:
+		adr := state.operand()
+		state.write(adr, state.A)
+		state.PC += 3
+		break
+
+	// INX SP
+	case 0x33:
+		state.SP++
+		state.PC++
+		break
+
+	// INR M
+	case 0x34:
+		offset := state.HL()
+		state.write(offset, state.inr(state.read(offset)))
+		state.PC++
+		break
+
+	// DCR M
+	case 0x35:
+		offset := state.HL()
+		state.write(offset, state.dcr(state.read(offset)))
+		state.PC++
+		break
+
+	// MVI M, D8
+	case 0x36:
+		state.write(state.HL(), state.read(state.PC+1))
+		state.PC += 2
+		break
+
+	// STC
+	case 0x37:
+		state.Cc.CY = true
+		state.PC++
+		break
+
+	// NOP (undocumented)
+	case 0x38:
+		state.PC++
+		break
+
+	// DAD SP
+	case 0x39:
+		state.dad(state.SP)
+		state.PC++
+		break
+
+	// LDA adr
+	case 0x3a:
+		adr := state.operand()
+		state.A = state.read(adr)
+		state.PC += 3
+		break
+
+	// DCX SP
+	case 0x3b:
+		state.SP--
+		state.PC++
+		break
+
+	// INR A
+	case 0x3c:
+		state.A = state.inr(state.A)
+		state.PC++
+		break
+
+	// DCR A
+	case 0x3d:
+		state.A = state.dcr(state.A)
+		state.PC++
+		break
+
+	// MVI A, D8
+	case 0x3e:
+		state.A = state.read(state.PC + 1)
+		state.PC += 2
+		break
+
+	// CMC
+	case 0x3f:
+		state.Cc.CY = !state.Cc.CY
+		state.PC++
+		break
+
+	// MOV B, B
+	case 0x40:
+		state.PC++
+		break
+
+	// MOV B, C
+	case 0x41:
+		state.B = state.C
+		state.PC++
+		break
+
+	// MOV B, D
+	case 0x42:
+		state.B = state.D
+		state.PC++
+		break
+
+	// MOV B, E
+	case 0x43:
+		state.B = state.E
+		state.PC++
+		break
+
+	// MOV B, H
+	case 0x44:
+		state.B = state.H
+		state.PC++
+		break
+
+	// MOV B, L
+	case 0x45:
+		state.B = state.L
+		state.PC++
+		break
+
+	// MOV B, M
+	case 0x46:
+		state.B = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV B, A
+	case 0x47:
+		state.B = state.A
+		state.PC++
+		break
+
+	// MOV C, B
+	case 0x48:
+		state.C = state.B
+		state.PC++
+		break
+
+	// MOV C, C
+	case 0x49:
+		state.PC++
+		break
+
+	// MOV C, D
+	case 0x4a:
+		state.C = state.D
+		state.PC++
+		break
+
+	// MOV C, E
+	case 0x4b:
+		state.C = state.E
+		state.PC++
+		break
+
+	// MOV C, H
+	case 0x4c:
+		state.C = state.H
+		state.PC++
+		break
+
+	// MOV C, L
+	case 0x4d:
+		state.C = state.L
+		state.PC++
+		break
+
+	// MOV C, M
+	case 0x4e:
+		state.C = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV C, A
+	case 0x4f:
+		state.C = state.A
+		state.PC++
+		break
+
+	// MOV D, B
+	case 0x50:
+		state.D = state.B
+		state.PC++
+		break
+
+	// MOV D, C
+	case 0x51:
+		state.D = state.C
+		state.PC++
+		break
+
+	// MOV D, D
+	case 0x52:
+		state.PC++
+		break
+
+	// MOV D, E
+	case 0x53:
+		state.D = state.E
+		state.PC++
+		break
+
+	// MOV D, H
+	case 0x54:
+		state.D = state.H
+		state.PC++
+		break
+
+	// MOV D, L
+	case 0x55:
+		state.D = state.L
+		state.PC++
+		break
+
+	// MOV D, M
+	case 0x56:
+		state.D = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV D, A
+	case 0x57:
+		state.D = state.A
+		state.PC++
+		break
+
+	// MOV E, B
+	case 0x58:
+		state.E = state.B
+		state.PC++
+		break
+
+	// MOV E, C
+	case 0x59:
+		state.E = state.C
+		state.PC++
+		break
+
+	// MOV E, D
+	case 0x5a:
+		state.E = state.D
+		state.PC++
+		break
+
+	// MOV E, E
+	case 0x5b:
+		state.PC++
+		break
+
+	// MOV E, H
+	case 0x5c:
+		state.E = state.H
+		state.PC++
+		break
+
+	// MOV E, L
+	case 0x5d:
+		state.E = state.L
+		state.PC++
+		break
+
+	// MOV E, M
+	case 0x5e:
+		state.E = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV E, A
+	case 0x5f:
+		state.E = state.A
+		state.PC++
+		break
+
+	// MOV H, B
+	case 0x60:
+		state.H = state.B
+		state.PC++
+		break
+
+	// MOV H, C
+	case 0x61:
+		state.H = state.C
+		state.PC++
+		break
+
+	// MOV H, D
+	case 0x62:
+		state.H = state.D
+		state.PC++
+		break
+
+	// MOV H, E
+	case 0x63:
+		state.H = state.E
+		state.PC++
+		break
+
+	// MOV H, H
+	case 0x64:
+		state.PC++
+		break
+
+	// MOV H, L
+	case 0x65:
+		state.H = state.L
+		state.PC++
+		break
+
+	// MOV H, M
+	case 0x66:
+		state.H = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV H, A
+	case 0x67:
+		state.H = state.A
+		state.PC++
+		break
+
+	// MOV L, B
+	case 0x68:
+		state.L = state.B
+		state.PC++
+		break
+
+	// MOV L, C
+	case 0x69:
+		state.L = state.C
+		state.PC++
+		break
+
+	// MOV L, D
+	case 0x6a:
+		state.L = state.D
+		state.PC++
+		break
+
+	// MOV L, E
+	case 0x6b:
+		state.L = state.E
+		state.PC++
+		break
+
+	// MOV L, H
+	case 0x6c:
+		state.L = state.H
+		state.PC++
+		break
+
+	// MOV L, L
+	case 0x6d:
+		state.PC++
+		break
+
+	// MOV L, M
+	case 0x6e:
+		state.L = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV L, A
+	case 0x6f:
+		state.L = state.A
+		state.PC++
+		break
+
+	// MOV M, B
+	case 0x70:
+		state.write(state.HL(), state.B)
+		state.PC++
+		break
+
+	// MOV M, C
+	case 0x71:
+		state.write(state.HL(), state.C)
+		state.PC++
+		break
+
+	// MOV M, D
+	case 0x72:
+		state.write(state.HL(), state.D)
+		state.PC++
+		break
+
+	// MOV M, E
+	case 0x73:
+		state.write(state.HL(), state.E)
+		state.PC++
+		break
+
+	// MOV M, H
+	case 0x74:
+		state.write(state.HL(), state.H)
+		state.PC++
+		break
+
+	// MOV M, L
+	case 0x75:
+		state.write(state.HL(), state.L)
+		state.PC++
+		break
+
+	// HLT
+	case 0x76:
+		state.Halted = true
+		state.PC++
+		if !state.IntEnable {
+			return cycles, state.haltError()
+		}
+		break
+
+	// MOV M, A
+	case 0x77:
+		state.write(state.HL(), state.A)
+		state.PC++
+		break
+
+	// MOV A, B
+	case 0x78:
+		state.A = state.B
+		state.PC++
+		break
+
+	// MOV A, C
+	case 0x79:
+		state.A = state.C
+		state.PC++
+		break
+
+	// MOV A, D
+	case 0x7a:
+		state.A = state.D
+		state.PC++
+		break
+
+	// MOV A, E
+	case 0x7b:
+		state.A = state.E
+		state.PC++
+		break
+
+	// MOV A, H
+	case 0x7c:
+		state.A = state.H
+		state.PC++
+		break
+
+	// MOV A, L
+	case 0x7d:
+		state.A = state.L
+		state.PC++
+		break
+
+	// MOV A, M
+	case 0x7e:
+		state.A = state.read(state.HL())
+		state.PC++
+		break
+
+	// MOV A, A
+	case 0x7f:
+		state.PC++
+		break
+
+	// ADD A, B
+	case 0x80:
+		state.add(state.B, false)
+		state.PC++
+		break
+
+	// ADD A, C
+	case 0x81:
+		state.add(state.C, false)
+		state.PC++
+		break
+
+	// ADD A, D
+	case 0x82:
+		state.add(state.D, false)
+		state.PC++
+		break
+
+	// ADD A, E
+	case 0x83:
+		state.add(state.E, false)
+		state.PC++
+		break
+
+	// ADD A, H
+	case 0x84:
+		state.add(state.H, false)
+		state.PC++
+		break
+
+	// ADD A, L
+	case 0x85:
+		state.add(state.L, false)
+		state.PC++
+		break
+
+	// ADD A, M
+	case 0x86:
+		offset := state.HL()
+		state.add(state.read(offset), false)
+		state.PC++
+		break
+
+	// ADD A, A
+	case 0x87:
+		state.add(state.A, false)
+		state.PC++
+		break
+
+	// ADC A, B
+	case 0x88:
+		state.add(state.B, state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, C
+	case 0x89:
+		state.add(state.C, state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, D
+	case 0x8a:
+		state.add(state.D, state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, E
+	case 0x8b:
+		state.add(state.E, state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, H
+	case 0x8c:
+		state.add(state.H, state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, L
+	case 0x8d:
+		state.add(state.L, state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, M
+	case 0x8e:
+		offset := state.HL()
+		state.add(state.read(offset), state.Cc.CY)
+		state.PC++
+		break
+
+	// ADC A, A
+	case 0x8f:
+		state.add(state.A, state.Cc.CY)
+		state.PC++
+		break
+
+	// SUB B
+	case 0x90:
+		state.sub(state.B, false)
+		state.PC++
+		break
+
+	// SUB C
+	case 0x91:
+		state.sub(state.C, false)
+		state.PC++
+		break
+
+	// SUB D
+	case 0x92:
+		state.sub(state.D, false)
+		state.PC++
+		break
+
+	// SUB E
+	case 0x93:
+		state.sub(state.E, false)
+		state.PC++
+		break
+
+	// SUB H
+	case 0x94:
+		state.sub(state.H, false)
+		state.PC++
+		break
+
+	// SUB L
+	case 0x95:
+		state.sub(state.L, false)
+		state.PC++
+		break
+
+	// SUB M
+	case 0x96:
+		offset := state.HL()
+		state.sub(state.read(offset), false)
+		state.PC++
+		break
+
+	// SUB A
+	case 0x97:
+		state.sub(state.A, false)
+		state.PC++
+		break
+
+	// SBB B
+	case 0x98:
+		state.sub(state.B, state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB C
+	case 0x99:
+		state.sub(state.C, state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB D
+	case 0x9a:
+		state.sub(state.D, state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB E
+	case 0x9b:
+		state.sub(state.E, state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB H
+	case 0x9c:
+		state.sub(state.H, state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB L
+	case 0x9d:
+		state.sub(state.L, state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB M
+	case 0x9e:
+		offset := state.HL()
+		state.sub(state.read(offset), state.Cc.CY)
+		state.PC++
+		break
+
+	// SBB A
+	case 0x9f:
+		state.sub(state.A, state.Cc.CY)
+		state.PC++
+		break
+
+	// ANA B
+	case 0xa0:
+		state.ana(state.B)
+		state.PC++
+		break
+
+	// ANA C
+	case 0xa1:
+		state.ana(state.C)
+		state.PC++
+		break
+
+	// ANA D
+	case 0xa2:
+		state.ana(state.D)
+		state.PC++
+		break
+
+	// ANA E
+	case 0xa3:
+		state.ana(state.E)
+		state.PC++
+		break
+
+	// ANA H
+	case 0xa4:
+		state.ana(state.H)
+		state.PC++
+		break
+
+	// ANA L
+	case 0xa5:
+		state.ana(state.L)
+		state.PC++
+		break
+
+	// ANA M
+	case 0xa6:
+		offset := state.HL()
+		state.ana(state.read(offset))
+		state.PC++
+		break
+
+	// ANA A
+	case 0xa7:
+		state.ana(state.A)
+		state.PC++
+		break
+
+	// XRA B
+	case 0xa8:
+		state.xra(state.B)
+		state.PC++
+		break
+
+	// XRA C
+	case 0xa9:
+		state.xra(state.C)
+		state.PC++
+		break
+
+	// XRA D
+	case 0xaa:
+		state.xra(state.D)
+		state.PC++
+		break
+
+	// XRA E
+	case 0xab:
+		state.xra(state.E)
+		state.PC++
+		break
+
+	// XRA H
+	case 0xac:
+		state.xra(state.H)
+		state.PC++
+		break
+
+	// XRA L
+	case 0xad:
+		state.xra(state.L)
+		state.PC++
+		break
+
+	// XRA M
+	case 0xae:
+		offset := state.HL()
+		state.xra(state.read(offset))
+		state.PC++
+		break
+
+	// XRA A
+	case 0xaf:
+		state.xra(state.A)
+		state.PC++
+		break
+
+	// ORA B
+	case 0xb0:
+		state.ora(state.B)
+		state.PC++
+		break
+
+	// ORA C
+	case 0xb1:
+		state.ora(state.C)
+		state.PC++
+		break
+
+	// ORA D
+	case 0xb2:
+		state.ora(state.D)
+		state.PC++
+		break
+
+	// ORA E
+	case 0xb3:
+		state.ora(state.E)
+		state.PC++
+		break
+
+	// ORA H
+	case 0xb4:
+		state.ora(state.H)
+		state.PC++
+		break
+
+	// ORA L
+	case 0xb5:
+		state.ora(state.L)
+		state.PC++
+		break
+
+	// ORA M
+	case 0xb6:
+		offset := state.HL()
+		state.ora(state.read(offset))
+		state.PC++
+		break
+
+	// ORA A
+	case 0xb7:
+		state.ora(state.A)
+		state.PC++
+		break
+
+	// CMP B
+	case 0xb8:
+		state.cmp(state.B)
+		state.PC++
+		break
+
+	// CMP C
+	case 0xb9:
+		state.cmp(state.C)
+		state.PC++
+		break
+
+	// CMP D
+	case 0xba:
+		state.cmp(state.D)
+		state.PC++
+		break
+
+	// CMP E
+	case 0xbb:
+		state.cmp(state.E)
+		state.PC++
+		break
+
+	// CMP H
+	case 0xbc:
+		state.cmp(state.H)
+		state.PC++
+		break
+
+	// CMP L
+	case 0xbd:
+		state.cmp(state.L)
+		state.PC++
+		break
+
+	// CMP M
+	case 0xbe:
+		offset := state.HL()
+		state.cmp(state.read(offset))
+		state.PC++
+		break
+
+	// CMP A
+	case 0xbf:
+		state.cmp(state.A)
+		state.PC++
+		break
+
+	// RNZ
+	case 0xc0:
+		if !state.Cc.Z {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// POP B
+	case 0xc1:
+		state.SetBC(state.pop())
+		state.PC++
+		break
+
+	// JNZ adr
+	case 0xc2:
+		if !state.Cc.Z {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// JMP adr
+	case 0xc3:
+		state.PC = state.operand()
+		break
+
+	// CNZ adr
+	case 0xc4:
+		if !state.Cc.Z {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// PUSH B
+	case 0xc5:
+		state.push(state.BC())
+		state.PC++
+		break
+
+	// ADI D8
+	case 0xc6:
+		state.add(state.read(state.PC+1), false)
+		state.PC += 2
+		break
+
+	// RST 0
+	case 0xc7:
+		state.PC++
+		state.call(0x0000)
+		break
+
+	// RZ
+	case 0xc8:
+		if state.Cc.Z {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// RET
+	case 0xc9:
+		state.ret()
+		break
+
+	// JZ adr
+	case 0xca:
+		if state.Cc.Z {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// JMP adr (undocumented)
+	case 0xcb:
+		state.PC = state.operand()
+		break
+
+	// CZ adr
+	case 0xcc:
+		if state.Cc.Z {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// CALL adr
+	case 0xcd:
+		adr := state.operand()
+		state.PC += 3
+		state.call(adr)
+		break
+
+	// ACI D8
+	case 0xce:
+		state.add(state.read(state.PC+1), state.Cc.CY)
+		state.PC += 2
+		break
+
+	// RST 1
+	case 0xcf:
+		state.PC++
+		state.call(0x0008)
+		break
+
+	// RNC
+	case 0xd0:
+		if !state.Cc.CY {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// POP D
+	case 0xd1:
+		state.SetDE(state.pop())
+		state.PC++
+		break
+
+	// JNC adr
+	case 0xd2:
+		if !state.Cc.CY {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// OUT D8
+	case 0xd3:
+		state.out(state.read(state.PC+1), state.A)
+		state.PC += 2
+		break
+
+	// CNC adr
+	case 0xd4:
+		if !state.Cc.CY {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// PUSH D
+	case 0xd5:
+		state.push(state.DE())
+		state.PC++
+		break
+
+	// SUI D8
+	case 0xd6:
+		state.sub(state.read(state.PC+1), false)
+		state.PC += 2
+		break
+
+	// RST 2
+	case 0xd7:
+		state.PC++
+		state.call(0x0010)
+		break
+
+	// RC
+	case 0xd8:
+		if state.Cc.CY {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// RET (undocumented)
+	case 0xd9:
+		state.ret()
+		break
+
+	// JC adr
+	case 0xda:
+		if state.Cc.CY {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// IN D8
+	case 0xdb:
+		state.A = state.in(state.read(state.PC + 1))
+		state.PC += 2
+		break
+
+	// CC adr
+	case 0xdc:
+		if state.Cc.CY {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// CALL adr (undocumented)
+	case 0xdd:
+		adr := state.operand()
+		state.PC += 3
+		state.call(adr)
+		break
+
+	// SBI D8
+	case 0xde:
+		state.sub(state.read(state.PC+1), state.Cc.CY)
+		state.PC += 2
+		break
+
+	// RST 3
+	case 0xdf:
+		state.PC++
+		state.call(0x0018)
+		break
+
+	// RPO
+	case 0xe0:
+		if !state.Cc.P {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// POP H
+	case 0xe1:
+		state.SetHL(state.pop())
+		state.PC++
+		break
+
+	// JPO adr
+	case 0xe2:
+		if !state.Cc.P {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// XTHL
+	case 0xe3:
+		hl := state.HL()
+		state.SetHL(state.read16(state.SP))
+		state.write(state.SP, uint8(hl&0xff))
+		state.write(state.SP+1, uint8(hl>>8))
+		state.PC++
+		break
+
+	// CPO adr
+	case 0xe4:
+		if !state.Cc.P {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// PUSH H
+	case 0xe5:
+		state.push(state.HL())
+		state.PC++
+		break
+
+	// ANI D8
+	case 0xe6:
+		state.ana(state.read(state.PC + 1))
+		state.PC += 2
+		break
+
+	// RST 4
+	case 0xe7:
+		state.PC++
+		state.call(0x0020)
+		break
+
+	// RPE
+	case 0xe8:
+		if state.Cc.P {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// PCHL
+	case 0xe9:
+		state.PC = state.HL()
+		break
+
+	// JPE adr
+	case 0xea:
+		if state.Cc.P {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// XCHG
+	case 0xeb:
+		state.D, state.H = state.H, state.D
+		state.E, state.L = state.L, state.E
+		state.PC++
+		break
+
+	// CPE adr
+	case 0xec:
+		if state.Cc.P {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// CALL adr (undocumented)
+	case 0xed:
+		adr := state.operand()
+		state.PC += 3
+		state.call(adr)
+		break
+
+	// XRI D8
+	case 0xee:
+		state.xra(state.read(state.PC + 1))
+		state.PC += 2
+		break
+
+	// RST 5
+	case 0xef:
+		state.PC++
+		state.call(0x0028)
+		break
+
+	// RP
+	case 0xf0:
+		if !state.Cc.S {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// POP PSW
+	case 0xf1:
+		state.SetPSW(state.pop())
+		state.PC++
+		break
+
+	// JP adr
+	case 0xf2:
+		if !state.Cc.S {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// DI disable interrupts
+	case 0xf3:
+		state.IntEnable = false
+		state.PC++
+		break
+
+	// CP adr
+	case 0xf4:
+		if !state.Cc.S {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// PUSH PSW
+	case 0xf5:
+		state.push(state.PSW())
+		state.PC++
+		break
+
+	// ORI D8
+	case 0xf6:
+		state.ora(state.read(state.PC + 1))
+		state.PC += 2
+		break
+
+	// RST 6
+	case 0xf7:
+		state.PC++
+		state.call(0x0030)
+		break
+
+	// RM
+	case 0xf8:
+		if state.Cc.S {
+			cycles += retTakenCycles
+			state.ret()
+		} else {
+			state.PC++
+		}
+		break
+
+	// SPHL
+	case 0xf9:
+		state.SP = state.HL()
+		state.PC++
+		break
+
+	// JM adr
+	case 0xfa:
+		if state.Cc.S {
+			state.PC = state.operand()
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// EI enable interrupts
+	case 0xfb:
+		state.IntEnable = true
+		state.eiDelay = true
+		state.PC++
+		break
+
+	// CM adr
+	case 0xfc:
+		if state.Cc.S {
+			cycles += callTakenCycles
+			adr := state.operand()
+			state.PC += 3
+			state.call(adr)
+		} else {
+			state.PC += 3
+		}
+		break
+
+	// CALL adr (undocumented)
+	case 0xfd:
+		adr := state.operand()
+		state.PC += 3
+		state.call(adr)
+		break
+
+	// CPI D8
+	case 0xfe:
+		state.cmp(state.read(state.PC + 1))
+		state.PC += 2
+		break
+
+	// RST 7
+	case 0xff:
+		state.PC++
+		state.call(0x0038)
+		break
+
+	default:
+		return 0, UnimplementedInstruction(state, opcode)
+	}
+	return cycles, nil
+}
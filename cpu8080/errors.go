@@ -0,0 +1,67 @@
+package cpu8080
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrHalted matches the error returned by Step when the CPU has executed
+	// HLT with interrupts disabled. Nothing but a reset can resume execution
+	// from that state.
+	ErrHalted = errors.New("cpu halted with interrupts disabled")
+
+	// ErrUnimplemented matches errors for opcodes the emulator cannot execute.
+	ErrUnimplemented = errors.New("unimplemented instruction")
+
+	// ErrIllegalOpcode matches errors for undocumented opcodes when
+	// State8080.TrapUndocumented is set.
+	ErrIllegalOpcode = errors.New("illegal opcode")
+)
+
+// OpcodeError reports an opcode that could not be executed. PC is left
+// pointing at the offending opcode.
+type OpcodeError struct {
+	Err       error // ErrUnimplemented or ErrIllegalOpcode
+	Opcode    uint8
+	PC        uint16
+	Registers Registers
+}
+
+func (e *OpcodeError) Error() string {
+	return fmt.Sprintf("%v %02X at %04X (%v)", e.Err, e.Opcode, e.PC, e.Registers)
+}
+
+func (e *OpcodeError) Unwrap() error {
+	return e.Err
+}
+
+// HaltError is returned by Step while the CPU is halted with interrupts
+// disabled. It matches ErrHalted.
+type HaltError struct {
+	PC        uint16 // address of the HLT instruction
+	Registers Registers
+}
+
+func (e *HaltError) Error() string {
+	return fmt.Sprintf("%v at %04X (%v)", ErrHalted, e.PC, e.Registers)
+}
+
+func (e *HaltError) Is(target error) bool {
+	return target == ErrHalted
+}
+
+// haltError builds the HaltError for a CPU that has just executed HLT.
+func (state *State8080) haltError() error {
+	return &HaltError{PC: state.PC - 1, Registers: state.Registers()}
+}
+
+// isUndocumented reports whether opcode is one of the undocumented aliases
+// of NOP, JMP, RET and CALL.
+func isUndocumented(opcode uint8) bool {
+	switch opcode {
+	case 0x08, 0x10, 0x18, 0x20, 0x28, 0x30, 0x38, 0xcb, 0xd9, 0xdd, 0xed, 0xfd:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package cpu8080
+
+// Bit positions of the condition flags in the PSW byte, as laid out by
+// PUSH PSW on the Intel 8080: S Z 0 AC 0 P 1 CY.
+const (
+	flagCY uint8 = 0x01
+	flagP  uint8 = 0x04
+	flagAC uint8 = 0x10
+	flagZ  uint8 = 0x40
+	flagS  uint8 = 0x80
+
+	// pswFixed holds the bits that always read back as 1 in the PSW.
+	pswFixed uint8 = 0x02
+)
+
+// zspTable holds the Z, S and P flag bits for every possible 8-bit result.
+var zspTable [256]uint8
+
+func init() {
+	for i := 0; i < 256; i++ {
+		var flags uint8
+		if i == 0 {
+			flags |= flagZ
+		}
+		if i&0x80 != 0 {
+			flags |= flagS
+		}
+		if !parity(uint8(i), 8) {
+			flags |= flagP
+		}
+		zspTable[i] = flags
+	}
+}
+
+// parity reports whether the low numBits of b contain an odd number of set
+// bits. The 8080 P flag is the inverse: it is set on even parity.
+func parity(b uint8, numBits uint8) bool {
+	var i uint8
+	var parity bool
+	for i = 0; i < numBits; i++ {
+		if (b & (1 << i)) != 0 {
+			parity = !parity
+		}
+	}
+	return parity
+}
+
+// setZSP sets the Z, S and P flags from an 8-bit result.
+func (cc *ConditionCodes) setZSP(value uint8) {
+	flags := zspTable[value]
+	cc.Z = flags&flagZ != 0
+	cc.S = flags&flagS != 0
+	cc.P = flags&flagP != 0
+}
+
+// PSW packs the condition codes into the flag byte pushed by PUSH PSW.
+func (cc ConditionCodes) PSW() uint8 {
+	psw := pswFixed
+	if cc.Z {
+		psw |= flagZ
+	}
+	if cc.S {
+		psw |= flagS
+	}
+	if cc.P {
+		psw |= flagP
+	}
+	if cc.CY {
+		psw |= flagCY
+	}
+	if cc.AC {
+		psw |= flagAC
+	}
+	return psw
+}
+
+// SetPSW unpacks a flag byte popped by POP PSW. Bits 1, 3 and 5 are ignored.
+func (cc *ConditionCodes) SetPSW(psw uint8) {
+	cc.Z = psw&flagZ != 0
+	cc.S = psw&flagS != 0
+	cc.P = psw&flagP != 0
+	cc.CY = psw&flagCY != 0
+	cc.AC = psw&flagAC != 0
+}
+
+// add adds value and an optional carry to A (ADD, ADC, ADI, ACI).
+func (state *State8080) add(value uint8, carry bool) {
+	var c uint16
+	if carry {
+		c = 1
+	}
+	answer := uint16(state.A) + uint16(value) + c
+	state.Cc.setZSP(uint8(answer))
+	state.Cc.CY = answer > 0xff
+	state.Cc.AC = (uint16(state.A&0x0f) + uint16(value&0x0f) + c) > 0x0f
+	state.A = uint8(answer)
+}
+
+// subtract computes A - value - borrow and sets all flags without storing
+// the result. The 8080 subtracts by adding the two's complement, so AC is
+// the carry out of bit 3 of that addition and CY is its inverted carry out
+// of bit 7.
+func (state *State8080) subtract(value uint8, borrow bool) uint8 {
+	var c uint16 = 1
+	if borrow {
+		c = 0
+	}
+	answer := uint16(state.A) + uint16(^value) + c
+	state.Cc.setZSP(uint8(answer))
+	state.Cc.CY = answer <= 0xff
+	state.Cc.AC = (uint16(state.A&0x0f) + uint16(^value&0x0f) + c) > 0x0f
+	return uint8(answer)
+}
+
+// sub subtracts value and an optional borrow from A (SUB, SBB, SUI, SBI).
+func (state *State8080) sub(value uint8, borrow bool) {
+	state.A = state.subtract(value, borrow)
+}
+
+// cmp compares value with A, setting flags as SUB would (CMP, CPI).
+func (state *State8080) cmp(value uint8) {
+	state.subtract(value, false)
+}
+
+// dad adds value to HL, setting only CY (DAD).
+func (state *State8080) dad(value uint16) {
+	hl := uint32(state.HL()) + uint32(value)
+	state.SetHL(uint16(hl))
+	state.Cc.CY = hl > 0xffff
+}
+
+// ana ands value into A (ANA, ANI). On the 8080, AC receives the logical
+// or of bit 3 of both operands.
+func (state *State8080) ana(value uint8) {
+	state.Cc.AC = ((state.A | value) & 0x08) != 0
+	state.A &= value
+	state.Cc.setZSP(state.A)
+	state.Cc.CY = false
+}
+
+// xra exclusive-ors value into A (XRA, XRI).
+func (state *State8080) xra(value uint8) {
+	state.A ^= value
+	state.Cc.setZSP(state.A)
+	state.Cc.CY = false
+	state.Cc.AC = false
+}
+
+// ora ors value into A (ORA, ORI).
+func (state *State8080) ora(value uint8) {
+	state.A |= value
+	state.Cc.setZSP(state.A)
+	state.Cc.CY = false
+	state.Cc.AC = false
+}
+
+// inr returns value+1 and sets every flag but CY (INR).
+func (state *State8080) inr(value uint8) uint8 {
+	value++
+	state.Cc.setZSP(value)
+	state.Cc.AC = (value & 0x0f) == 0x00
+	return value
+}
+
+// dcr returns value-1 and sets every flag but CY (DCR).
+func (state *State8080) dcr(value uint8) uint8 {
+	value--
+	state.Cc.setZSP(value)
+	state.Cc.AC = (value & 0x0f) != 0x0f
+	return value
+}
+
+// daa adjusts A to packed BCD after an addition (DAA).
+func (state *State8080) daa() {
+	var correction uint8
+	carry := state.Cc.CY
+	if (state.A&0x0f) > 9 || state.Cc.AC {
+		correction |= 0x06
+	}
+	if (state.A>>4) > 9 || ((state.A>>4) >= 9 && (state.A&0x0f) > 9) || state.Cc.CY {
+		correction |= 0x60
+		carry = true
+	}
+	state.add(correction, false)
+	state.Cc.CY = carry
+}
@@ -0,0 +1,172 @@
+package cpu8080
+
+import (
+	"errors"
+	"testing"
+)
+
+// fuzzCPU builds a CPU with 64 KiB of RAM holding code at pc and registers
+// taken from the fuzz input.
+func fuzzCPU(code []byte, pc, sp uint16, a, f, b, c, d, e, h, l uint8) (*State8080, RAM) {
+	ram := NewRAM(0x10000)
+	state := NewState8080WithBus(ram)
+	state.Load(pc, code)
+	state.PC, state.SP = pc, sp
+	state.A, state.B, state.C, state.D, state.E, state.H, state.L = a, b, c, d, e, h, l
+	state.Cc.SetPSW(f)
+	return state, ram
+}
+
+// fillMemory writes the memory the instruction at state.PC may read besides
+// its own bytes: top at SP, atHL, atDE and atBC where those pairs point, and
+// word at the instruction's address operand. The code is loaded again last so
+// that it stays intact where they overlap.
+func fillMemory(state *State8080, code []byte, top, word uint16, atHL, atDE, atBC uint8) {
+	write16 := func(address, value uint16) {
+		state.write(address, uint8(value&0xff))
+		state.write(address+1, uint8(value>>8))
+	}
+	write16(state.SP, top)
+	state.write(state.HL(), atHL)
+	state.write(state.DE(), atDE)
+	state.write(state.BC(), atBC)
+	write16(state.operand(), word)
+	state.Load(state.PC, code)
+}
+
+// destinations returns the addresses the instruction at state.PC may leave
+// in PC, read before it executes. ok is false for instructions that do not
+// transfer control, which must move PC past themselves.
+func destinations(state *State8080, opcode uint8) (dests []uint16, ok bool) {
+	next := state.PC + uint16(InstructionLength(opcode))
+	operand := state.operand()
+	top := state.read16(state.SP)
+	switch {
+	case opcode == 0xc3 || opcode == 0xcb:
+		// JMP
+		return []uint16{operand}, true
+	case opcode&0xcf == 0xcd:
+		// CALL
+		return []uint16{operand}, true
+	case opcode == 0xc9 || opcode == 0xd9:
+		// RET
+		return []uint16{top}, true
+	case opcode == 0xe9:
+		// PCHL
+		return []uint16{state.HL()}, true
+	case opcode&0xc7 == 0xc2, opcode&0xc7 == 0xc4:
+		// conditional JMP and CALL
+		return []uint16{operand, next}, true
+	case opcode&0xc7 == 0xc0:
+		// conditional RET
+		return []uint16{top, next}, true
+	case opcode&0xc7 == 0xc7:
+		// RST
+		return []uint16{uint16(opcode & 0x38)}, true
+	}
+	return nil, false
+}
+
+// checkError fails t unless err is nil or one of the error types Step
+// documents.
+func checkError(t *testing.T, err error) {
+	t.Helper()
+	var opErr *OpcodeError
+	var haltErr *HaltError
+	switch {
+	case err == nil:
+	case errors.As(err, &opErr):
+		if !errors.Is(err, ErrUnimplemented) && !errors.Is(err, ErrIllegalOpcode) {
+			t.Errorf("OpcodeError with unexpected cause: %v", err)
+		}
+	case errors.As(err, &haltErr):
+		if !errors.Is(err, ErrHalted) {
+			t.Errorf("HaltError does not match ErrHalted: %v", err)
+		}
+	default:
+		t.Errorf("untyped error %T: %v", err, err)
+	}
+}
+
+func FuzzEmulate8080Op(f *testing.F) {
+	for op := 0; op < 0x100; op++ {
+		f.Add([]byte{uint8(op), 0x34, 0x12}, uint16(0x0100), uint16(0x3000),
+			uint8(0x6c), uint8(0x02), uint8(0x20), uint8(0x00), uint8(0x20), uint8(0x10), uint8(0x20), uint8(0x40),
+			uint16(0x0200), uint16(0x5a3c), uint8(0x7f), uint8(0x80), uint8(0x01), false, false)
+	}
+	f.Add([]byte{0xcd, 0xff, 0xff}, uint16(0xfffe), uint16(0x0001),
+		uint8(0xff), uint8(0xd7), uint8(0xff), uint8(0xff), uint8(0xff), uint8(0xff), uint8(0xff), uint8(0xff),
+		uint16(0xffff), uint16(0xffff), uint8(0xff), uint8(0xff), uint8(0xff), true, false)
+	f.Add([]byte{0xcb, 0x00, 0x00}, uint16(0x0000), uint16(0x0000),
+		uint8(0x00), uint8(0x00), uint8(0x00), uint8(0x00), uint8(0x00), uint8(0x00), uint8(0x00), uint8(0x00),
+		uint16(0x0000), uint16(0x0000), uint8(0x00), uint8(0x00), uint8(0x00), false, true)
+	f.Fuzz(func(t *testing.T, code []byte, pc, sp uint16, a, flags, b, c, d, e, h, l uint8,
+		top, word uint16, atHL, atDE, atBC uint8, intEnable, trap bool) {
+		if len(code) == 0 {
+			return
+		}
+		state, _ := fuzzCPU(code, pc, sp, a, flags, b, c, d, e, h, l)
+		fillMemory(state, code, top, word, atHL, atDE, atBC)
+		state.IntEnable = intEnable
+		state.TrapUndocumented = trap
+		opcode := code[0]
+		dests, transfers := destinations(state, opcode)
+
+		cycles, err := Emulate8080Op(state)
+		checkError(t, err)
+
+		if psw := state.Cc.PSW(); psw&0x2a != pswFixed {
+			t.Errorf("%02X: PSW %02X has the wrong fixed bits", opcode, psw)
+		}
+		var opErr *OpcodeError
+		if errors.As(err, &opErr) {
+			if !trap || !isUndocumented(opcode) {
+				t.Errorf("%02X: unexpected %v", opcode, err)
+			}
+			if state.PC != pc || opErr.PC != pc || opErr.Opcode != opcode {
+				t.Errorf("%02X: error %v, PC %04X, want PC left at %04X", opcode, err, state.PC, pc)
+			}
+			return
+		}
+
+		if want := int(cycles8080[opcode]); cycles != want && cycles != want+callTakenCycles {
+			t.Errorf("%02X: took %d cycles, want %d or %d", opcode, cycles, want, want+callTakenCycles)
+		}
+		if !transfers {
+			if want := pc + uint16(InstructionLength(opcode)); state.PC != want {
+				t.Errorf("%02X at %04X: PC = %04X, want %04X", opcode, pc, state.PC, want)
+			}
+			return
+		}
+		for _, dest := range dests {
+			if state.PC == dest {
+				return
+			}
+		}
+		t.Errorf("%02X at %04X: PC = %04X, want one of %04X", opcode, pc, state.PC, dests)
+	})
+}
+
+// FuzzStep runs a short program through Step on memory of any size,
+// requesting an interrupt part way through, and checks that it neither
+// panics nor returns an error other than the documented ones.
+func FuzzStep(f *testing.F) {
+	f.Add([]byte{0xfb, 0x00, 0x76, 0xc3, 0x00, 0x00}, uint16(0xffff), uint8(2), RST1)
+	f.Add([]byte{0x31, 0x00, 0x00, 0xcd, 0xfe, 0xff, 0xc9}, uint16(0x00ff), uint8(0), RST7)
+	f.Add([]byte{0x76}, uint16(0), uint8(0), RST0)
+	f.Fuzz(func(t *testing.T, code []byte, size uint16, at uint8, interrupt uint8) {
+		ram := NewRAM(int(size) + 1)
+		copy(ram, code)
+		state := NewState8080WithBus(ram)
+		for i := 0; i < 64; i++ {
+			if i == int(at) {
+				state.Interrupt(interrupt)
+			}
+			_, err := state.Step()
+			checkError(t, err)
+			if err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package cpu8080
+
+import "errors"
+
+// Common interrupt instructions. RST n pushes PC and jumps to n*8.
+const (
+	RST0 uint8 = 0xc7
+	RST1 uint8 = 0xcf
+	RST2 uint8 = 0xd7
+	RST3 uint8 = 0xdf
+	RST4 uint8 = 0xe7
+	RST5 uint8 = 0xef
+	RST6 uint8 = 0xf7
+	RST7 uint8 = 0xff
+)
+
+// Interrupt requests an interrupt that places opcode on the data bus,
+// usually one of RST0-RST7. An instruction longer than one byte, such as
+// CALL, takes its operand bytes from operands rather than from memory;
+// missing operands read as 0xff, as from an undriven bus. The request stays
+// pending until interrupts are enabled, at which point the next Step
+// executes the instruction in place of the one at PC and disables
+// interrupts. PC is left pointing at the interrupted instruction, which is
+// the address RST and CALL push. A newer request replaces a pending one.
+func (state *State8080) Interrupt(opcode uint8, operands ...uint8) {
+	state.interruptPending = true
+	state.interruptOpcode = opcode
+	state.interruptOperands = [2]uint8{0xff, 0xff}
+	copy(state.interruptOperands[:], operands)
+}
+
+// InterruptPending reports whether an interrupt is waiting to be
+// acknowledged.
+func (state *State8080) InterruptPending() bool {
+	return state.interruptPending
+}
+
+// interruptReady reports whether the next Step should acknowledge the pending
+// interrupt. Interrupts are held off for one instruction after EI so that
+// EI; RET sequences complete before the next interrupt.
+func (state *State8080) interruptReady() bool {
+	return state.interruptPending && state.IntEnable && !state.eiDelay
+}
+
+// acknowledgeInterrupt disables interrupts and executes the instruction
+// supplied with the pending interrupt. The instruction runs as though it
+// ended just before PC, with its operand bytes supplied by interruptBus, so
+// that it leaves PC where it was unless it jumps and pushes PC as the
+// return address.
+func (state *State8080) acknowledgeInterrupt() (int, error) {
+	state.interruptPending = false
+	state.IntEnable = false
+
+	pc := state.PC
+	n := uint16(InstructionLength(state.interruptOpcode))
+	bus := state.Bus
+	state.Bus = &interruptBus{Bus: bus, start: pc - n + 1, operands: state.interruptOperands[:n-1]}
+	state.PC = pc - n
+	cycles, err := emulateOpcode(state, state.interruptOpcode)
+	state.Bus = bus
+
+	var opErr *OpcodeError
+	if errors.As(err, &opErr) {
+		state.PC = pc
+		opErr.PC = pc
+	}
+	return cycles, err
+}
+
+// interruptBus supplies the operand bytes of an instruction executed by an
+// interrupt acknowledge. The first read of each operand address returns the
+// operand; every other access goes to the real bus.
+type interruptBus struct {
+	Bus
+	start    uint16 // address of the first operand
+	operands []uint8
+	fetched  [2]bool
+}
+
+func (b *interruptBus) Read(address uint16) uint8 {
+	if i := address - b.start; int(i) < len(b.operands) && !b.fetched[i] {
+		b.fetched[i] = true
+		return b.operands[i]
+	}
+	return b.Bus.Read(address)
+}
@@ -0,0 +1,66 @@
+package cpu8080
+
+import "testing"
+
+// interruptCPU returns a CPU with interrupts enabled, executing NOPs at 0x0002
+// with the stack at 0x3000 and memory at HL holding 0x55.
+func interruptCPU() *State8080 {
+	state := NewState8080(nil)
+	state.PC, state.SP = 0x0002, 0x3000
+	state.SetHL(0x2000)
+	state.write(0x2000, 0x55)
+	state.IntEnable = true
+	return state
+}
+
+func TestInterruptAcknowledge(t *testing.T) {
+	tests := []struct {
+		name     string
+		opcode   uint8
+		operands []uint8
+		a        uint8
+		pc, sp   uint16
+		pushed   uint16
+	}{
+		{"RST 1", RST1, nil, 0x00, 0x0008, 0x2ffe, 0x0002},
+		{"MVI A", 0x3e, []uint8{0x42}, 0x42, 0x0002, 0x3000, 0},
+		{"MVI A without operand", 0x3e, nil, 0xff, 0x0002, 0x3000, 0},
+		{"MOV A,M", 0x7e, []uint8{0x99}, 0x55, 0x0002, 0x3000, 0},
+		{"CALL", 0xcd, []uint8{0x34, 0x12}, 0x00, 0x1234, 0x2ffe, 0x0002},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := interruptCPU()
+			state.Interrupt(tc.opcode, tc.operands...)
+			if _, err := state.Step(); err != nil {
+				t.Fatal(err)
+			}
+			if state.A != tc.a || state.PC != tc.pc || state.SP != tc.sp {
+				t.Errorf("A=%02X PC=%04X SP=%04X, want A=%02X PC=%04X SP=%04X", state.A, state.PC, state.SP, tc.a, tc.pc, tc.sp)
+			}
+			if tc.sp != 0x3000 {
+				if pushed := uint16(state.read(0x2fff))<<8 | uint16(state.read(0x2ffe)); pushed != tc.pushed {
+					t.Errorf("pushed %04X, want %04X", pushed, tc.pushed)
+				}
+			}
+			if state.IntEnable || state.InterruptPending() {
+				t.Errorf("IntEnable=%v pending=%v after acknowledge", state.IntEnable, state.InterruptPending())
+			}
+		})
+	}
+}
+
+func TestInterruptAfterEI(t *testing.T) {
+	state := interruptCPU()
+	state.IntEnable = false
+	state.Load(0x0002, []byte{0xfb, 0x00, 0x00}) // EI; NOP; NOP
+	state.Interrupt(RST2)
+	for i, want := range []uint16{0x0003, 0x0004, 0x0010} {
+		if _, err := state.Step(); err != nil {
+			t.Fatal(err)
+		}
+		if state.PC != want {
+			t.Fatalf("step %d: PC=%04X, want %04X", i, state.PC, want)
+		}
+	}
+}
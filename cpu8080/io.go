@@ -0,0 +1,67 @@
+package cpu8080
+
+import "log"
+
+// Device is hardware attached to one or more I/O ports. In is called for IN
+// and returns the value placed in A; Out is called for OUT with the value of A.
+type Device interface {
+	In(port uint8) uint8
+	Out(port uint8, value uint8)
+}
+
+// Ports dispatches IN and OUT to the devices registered for each port.
+// Unmapped ports go to Default, or read as 0 and ignore writes when Default
+// is nil. If Logger is set, every access is logged to it.
+type Ports struct {
+	Default Device
+	Logger  *log.Logger
+
+	devices [256]Device
+}
+
+// NewPorts returns a port map with nothing attached.
+func NewPorts() *Ports {
+	return &Ports{}
+}
+
+// Attach registers device for each of the given ports, replacing any device
+// previously registered there.
+func (p *Ports) Attach(device Device, ports ...uint8) {
+	for _, port := range ports {
+		p.devices[port] = device
+	}
+}
+
+// Detach removes the device registered for each of the given ports.
+func (p *Ports) Detach(ports ...uint8) {
+	for _, port := range ports {
+		p.devices[port] = nil
+	}
+}
+
+func (p *Ports) device(port uint8) Device {
+	if device := p.devices[port]; device != nil {
+		return device
+	}
+	return p.Default
+}
+
+func (p *Ports) In(port uint8) uint8 {
+	var value uint8
+	if device := p.device(port); device != nil {
+		value = device.In(port)
+	}
+	if p.Logger != nil {
+		p.Logger.Printf("IN  %02X -> %02X", port, value)
+	}
+	return value
+}
+
+func (p *Ports) Out(port uint8, value uint8) {
+	if p.Logger != nil {
+		p.Logger.Printf("OUT %02X <- %02X", port, value)
+	}
+	if device := p.device(port); device != nil {
+		device.Out(port, value)
+	}
+}
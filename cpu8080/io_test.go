@@ -0,0 +1,108 @@
+package cpu8080
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+// recordDevice answers every IN with its value and logs every access.
+type recordDevice struct {
+	name  string
+	value uint8
+	log   *[]string
+}
+
+func (d *recordDevice) In(port uint8) uint8 {
+	*d.log = append(*d.log, fmt.Sprintf("%s in %d", d.name, port))
+	return d.value
+}
+
+func (d *recordDevice) Out(port uint8, value uint8) {
+	*d.log = append(*d.log, fmt.Sprintf("%s out %d %02X", d.name, port, value))
+}
+
+func TestPorts(t *testing.T) {
+	var accesses []string
+	a := &recordDevice{"a", 0xa0, &accesses}
+	b := &recordDevice{"b", 0xb0, &accesses}
+	def := &recordDevice{"default", 0xd0, &accesses}
+
+	p := NewPorts()
+	p.Attach(a, 1, 2)
+	p.Attach(b, 2, 3) // replaces a on port 2
+
+	// Each step does IN when out is false and OUT otherwise, and expects
+	// the value read (0 for OUT) and the device accesses.
+	steps := []struct {
+		name  string
+		setup func()
+		port  uint8
+		out   bool
+		want  uint8
+		log   string
+	}{
+		{"attached", nil, 1, false, 0xa0, "a in 1"},
+		{"attached out", nil, 1, true, 0, "a out 1 55"},
+		{"replaced", nil, 2, false, 0xb0, "b in 2"},
+		{"unmapped reads 0", nil, 7, false, 0x00, ""},
+		{"unmapped ignores writes", nil, 7, true, 0, ""},
+		{"default", func() { p.Default = def }, 7, false, 0xd0, "default in 7"},
+		{"default out", nil, 0xff, true, 0, "default out 255 55"},
+		{"mapped over default", nil, 3, false, 0xb0, "b in 3"},
+		{"detached to default", func() { p.Detach(2, 3) }, 2, false, 0xd0, "default in 2"},
+		{"detach leaves others", nil, 1, false, 0xa0, "a in 1"},
+		{"detached without default", func() { p.Default = nil }, 3, true, 0, ""},
+	}
+	for _, step := range steps {
+		if step.setup != nil {
+			step.setup()
+		}
+		accesses = nil
+		var got uint8
+		if step.out {
+			p.Out(step.port, 0x55)
+		} else {
+			got = p.In(step.port)
+		}
+		if got != step.want {
+			t.Errorf("%s: read %02X, want %02X", step.name, got, step.want)
+		}
+		if seen := strings.Join(accesses, "; "); seen != step.log {
+			t.Errorf("%s: accesses %q, want %q", step.name, seen, step.log)
+		}
+	}
+}
+
+func TestPortsLogger(t *testing.T) {
+	var accesses []string
+	var logged bytes.Buffer
+	p := NewPorts()
+	p.Attach(&recordDevice{"a", 0x3c, &accesses}, 0x10)
+	p.Logger = log.New(&logged, "", 0)
+
+	state := NewState8080([]byte{
+		0xdb, 0x10, // IN 10
+		0xd3, 0x20, // OUT 20
+		0xdb, 0x30, // IN 30
+	})
+	state.IO = p
+	for i := 0; i < 3; i++ {
+		if _, err := state.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "IN  10 -> 3C\nOUT 20 <- 3C\nIN  30 -> 00\n"
+	if logged.String() != want {
+		t.Errorf("logged %q, want %q", logged.String(), want)
+	}
+	if state.A != 0x00 {
+		t.Errorf("A = %02X after reading an unmapped port, want 00", state.A)
+	}
+	if len(accesses) != 1 {
+		t.Errorf("device accesses %v, want only the IN from port 10", accesses)
+	}
+}
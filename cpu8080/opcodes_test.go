@@ -0,0 +1,427 @@
+package cpu8080
+
+import (
+	"fmt"
+	"testing"
+)
+
+// cpuState is the part of the CPU that the opcode tests set up and check.
+type cpuState struct {
+	A, B, C, D, E, H, L uint8
+	F                   uint8 // condition flags in PSW layout, without the fixed bit
+	SP, PC              uint16
+	IntEnable           bool
+	Halted              bool
+
+	// Mem holds bytes to place in memory before the instruction runs; in
+	// the expected state it holds the bytes the instruction writes.
+	Mem map[uint16]uint8
+	// Ports holds the values IN reads; in the expected state it holds the
+	// values OUT writes.
+	Ports map[uint8]uint8
+}
+
+// initialState is the state every test starts from before its setup runs.
+// Each register holds a different value so that writes to the wrong one
+// show up.
+func initialState() cpuState {
+	return cpuState{
+		A: 0x01, B: 0x02, C: 0x03, D: 0x04, E: 0x05, H: 0x20, L: 0x40,
+		SP: 0x3000, PC: 0x0100,
+		Mem: map[uint16]uint8{}, Ports: map[uint8]uint8{},
+	}
+}
+
+// expected returns the state s should be in after an instruction of length
+// n that changes nothing but PC.
+func (s cpuState) expected(n int) cpuState {
+	s.PC += uint16(n)
+	s.Mem = map[uint16]uint8{}
+	s.Ports = map[uint8]uint8{}
+	return s
+}
+
+// opcodeTest is one row of the opcode table: an instruction, the changes to
+// make to initialState before running it, and the changes it should make in
+// turn. PC is expected to move past the instruction unless want sets it.
+type opcodeTest struct {
+	name   string
+	code   []byte
+	setup  func(s *cpuState)
+	want   func(s *cpuState)
+	cycles int
+}
+
+// testPorts is the I/O device of the opcode tests.
+type testPorts struct {
+	in, out map[uint8]uint8
+}
+
+func (p *testPorts) In(port uint8) uint8 {
+	return p.in[port]
+}
+
+func (p *testPorts) Out(port uint8, value uint8) {
+	p.out[port] = value
+}
+
+func (tc opcodeTest) run(t *testing.T) {
+	in := initialState()
+	if tc.setup != nil {
+		tc.setup(&in)
+	}
+	want := in.expected(len(tc.code))
+	if tc.want != nil {
+		tc.want(&want)
+	}
+
+	ram := NewRAM(0x10000)
+	for addr, value := range in.Mem {
+		ram[addr] = value
+	}
+	for i, b := range tc.code {
+		ram[in.PC+uint16(i)] = b
+	}
+	wantRAM := append(RAM(nil), ram...)
+	for addr, value := range want.Mem {
+		wantRAM[addr] = value
+	}
+
+	state := NewState8080WithBus(ram)
+	state.A, state.B, state.C, state.D, state.E, state.H, state.L = in.A, in.B, in.C, in.D, in.E, in.H, in.L
+	state.SP, state.PC = in.SP, in.PC
+	state.Cc.SetPSW(in.F)
+	state.IntEnable = in.IntEnable
+	ports := &testPorts{in: in.Ports, out: map[uint8]uint8{}}
+	state.IO = ports
+
+	cycles, err := state.Step()
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	got := cpuState{
+		A: state.A, B: state.B, C: state.C, D: state.D, E: state.E, H: state.H, L: state.L,
+		F: state.Cc.PSW() &^ pswFixed, SP: state.SP, PC: state.PC,
+		IntEnable: state.IntEnable, Halted: state.Halted,
+	}
+	check := func(name string, got, want interface{}) {
+		if got != want {
+			t.Errorf("%s = %#x, want %#x", name, got, want)
+		}
+	}
+	check("A", got.A, want.A)
+	check("B", got.B, want.B)
+	check("C", got.C, want.C)
+	check("D", got.D, want.D)
+	check("E", got.E, want.E)
+	check("H", got.H, want.H)
+	check("L", got.L, want.L)
+	check("flags", got.F, want.F)
+	check("SP", got.SP, want.SP)
+	check("PC", got.PC, want.PC)
+	check("IntEnable", got.IntEnable, want.IntEnable)
+	check("Halted", got.Halted, want.Halted)
+	check("cycles", cycles, tc.cycles)
+	if psw := state.Cc.PSW(); psw&0x2a != pswFixed {
+		t.Errorf("PSW %#02x has the wrong fixed bits", psw)
+	}
+
+	for addr := range ram {
+		if ram[addr] != wantRAM[addr] {
+			t.Errorf("memory[%04X] = %#02x, want %#02x", addr, ram[addr], wantRAM[addr])
+		}
+	}
+	for port, value := range want.Ports {
+		if got, ok := ports.out[port]; !ok || got != value {
+			t.Errorf("port %02X = %#02x (written %v), want %#02x", port, got, ok, value)
+		}
+	}
+	for port, value := range ports.out {
+		if _, ok := want.Ports[port]; !ok {
+			t.Errorf("unexpected write of %#02x to port %02X", value, port)
+		}
+	}
+}
+
+// opcodeTests covers every opcode. Rows are grouped like the opcode map;
+// the regular MOV, arithmetic and conditional groups are generated below.
+var opcodeTests = []opcodeTest{
+	{"NOP", []byte{0x00}, nil, nil, 4},
+	{"LXI B", []byte{0x01, 0x34, 0x12}, nil, func(s *cpuState) { s.B, s.C = 0x12, 0x34 }, 10},
+	{"STAX B", []byte{0x02}, func(s *cpuState) { s.A, s.B, s.C = 0x5a, 0x24, 0x00 }, func(s *cpuState) { s.Mem[0x2400] = 0x5a }, 7},
+	{"INX B", []byte{0x03}, func(s *cpuState) { s.B, s.C = 0x12, 0xff }, func(s *cpuState) { s.B, s.C = 0x13, 0x00 }, 5},
+	{"INR B", []byte{0x04}, func(s *cpuState) { s.B, s.F = 0x0f, flagCY }, func(s *cpuState) { s.B, s.F = 0x10, flagCY|flagAC }, 5},
+	{"DCR B", []byte{0x05}, func(s *cpuState) { s.B, s.F = 0x01, flagCY }, func(s *cpuState) { s.B, s.F = 0x00, flagCY|flagZ|flagP|flagAC }, 5},
+	{"MVI B", []byte{0x06, 0x5a}, nil, func(s *cpuState) { s.B = 0x5a }, 7},
+	{"RLC", []byte{0x07}, func(s *cpuState) { s.A, s.F = 0xa5, flagZ }, func(s *cpuState) { s.A, s.F = 0x4b, flagZ|flagCY }, 4},
+	{"*NOP 08", []byte{0x08}, nil, nil, 4},
+	{"DAD B", []byte{0x09}, func(s *cpuState) { s.H, s.L, s.B, s.C, s.F = 0xa0, 0x00, 0x60, 0x01, flagZ }, func(s *cpuState) { s.H, s.L, s.F = 0x00, 0x01, flagZ|flagCY }, 10},
+	{"LDAX B", []byte{0x0a}, func(s *cpuState) { s.B, s.C, s.Mem[0x2400] = 0x24, 0x00, 0x99 }, func(s *cpuState) { s.A = 0x99 }, 7},
+	{"DCX B", []byte{0x0b}, func(s *cpuState) { s.B, s.C = 0x12, 0x00 }, func(s *cpuState) { s.B, s.C = 0x11, 0xff }, 5},
+	{"INR C", []byte{0x0c}, func(s *cpuState) { s.C = 0xff }, func(s *cpuState) { s.C, s.F = 0x00, flagZ|flagP|flagAC }, 5},
+	{"DCR C", []byte{0x0d}, func(s *cpuState) { s.C = 0x80 }, func(s *cpuState) { s.C = 0x7f }, 5},
+	{"MVI C", []byte{0x0e, 0x77}, nil, func(s *cpuState) { s.C = 0x77 }, 7},
+	{"RRC", []byte{0x0f}, nil, func(s *cpuState) { s.A, s.F = 0x80, flagCY }, 4},
+
+	{"*NOP 10", []byte{0x10}, nil, nil, 4},
+	{"LXI D", []byte{0x11, 0xef, 0xbe}, nil, func(s *cpuState) { s.D, s.E = 0xbe, 0xef }, 10},
+	{"STAX D", []byte{0x12}, func(s *cpuState) { s.A, s.D, s.E = 0x3c, 0x24, 0x01 }, func(s *cpuState) { s.Mem[0x2401] = 0x3c }, 7},
+	{"INX D", []byte{0x13}, func(s *cpuState) { s.D, s.E = 0xff, 0xff }, func(s *cpuState) { s.D, s.E = 0x00, 0x00 }, 5},
+	{"INR D", []byte{0x14}, func(s *cpuState) { s.D = 0x7f }, func(s *cpuState) { s.D, s.F = 0x80, flagS|flagAC }, 5},
+	{"DCR D", []byte{0x15}, func(s *cpuState) { s.D = 0x00 }, func(s *cpuState) { s.D, s.F = 0xff, flagS|flagP }, 5},
+	{"MVI D", []byte{0x16, 0xd0}, nil, func(s *cpuState) { s.D = 0xd0 }, 7},
+	{"RAL", []byte{0x17}, func(s *cpuState) { s.A = 0x95 }, func(s *cpuState) { s.A, s.F = 0x2a, flagCY }, 4},
+	{"*NOP 18", []byte{0x18}, nil, nil, 4},
+	{"DAD D", []byte{0x19}, func(s *cpuState) { s.H, s.L, s.D, s.E, s.F = 0x12, 0x34, 0x11, 0x11, flagCY }, func(s *cpuState) { s.H, s.L, s.F = 0x23, 0x45, 0 }, 10},
+	{"LDAX D", []byte{0x1a}, func(s *cpuState) { s.D, s.E, s.Mem[0x2410] = 0x24, 0x10, 0x42 }, func(s *cpuState) { s.A = 0x42 }, 7},
+	{"DCX D", []byte{0x1b}, func(s *cpuState) { s.D, s.E = 0x00, 0x00 }, func(s *cpuState) { s.D, s.E = 0xff, 0xff }, 5},
+	{"INR E", []byte{0x1c}, nil, func(s *cpuState) { s.E, s.F = 0x06, flagP }, 5},
+	{"DCR E", []byte{0x1d}, func(s *cpuState) { s.E = 0x10 }, func(s *cpuState) { s.E, s.F = 0x0f, flagP }, 5},
+	{"MVI E", []byte{0x1e, 0xe0}, nil, func(s *cpuState) { s.E = 0xe0 }, 7},
+	{"RAR", []byte{0x1f}, func(s *cpuState) { s.F = flagCY }, func(s *cpuState) { s.A, s.F = 0x80, flagCY }, 4},
+
+	{"*NOP 20", []byte{0x20}, nil, nil, 4},
+	{"LXI H", []byte{0x21, 0x78, 0x56}, nil, func(s *cpuState) { s.H, s.L = 0x56, 0x78 }, 10},
+	{"SHLD", []byte{0x22, 0x00, 0x25}, nil, func(s *cpuState) { s.Mem[0x2500], s.Mem[0x2501] = 0x40, 0x20 }, 16},
+	{"INX H", []byte{0x23}, func(s *cpuState) { s.L = 0xff }, func(s *cpuState) { s.H, s.L = 0x21, 0x00 }, 5},
+	{"INR H", []byte{0x24}, nil, func(s *cpuState) { s.H, s.F = 0x21, flagP }, 5},
+	{"DCR H", []byte{0x25}, nil, func(s *cpuState) { s.H = 0x1f }, 5},
+	{"MVI H", []byte{0x26, 0x44}, nil, func(s *cpuState) { s.H = 0x44 }, 7},
+	{"DAA", []byte{0x27}, func(s *cpuState) { s.A = 0x9b }, func(s *cpuState) { s.A, s.F = 0x01, flagAC|flagCY }, 4},
+	{"*NOP 28", []byte{0x28}, nil, nil, 4},
+	{"DAD H", []byte{0x29}, func(s *cpuState) { s.H, s.L = 0x84, 0x21 }, func(s *cpuState) { s.H, s.L, s.F = 0x08, 0x42, flagCY }, 10},
+	{"LHLD", []byte{0x2a, 0x00, 0x25}, func(s *cpuState) { s.Mem[0x2500], s.Mem[0x2501] = 0xcd, 0xab }, func(s *cpuState) { s.H, s.L = 0xab, 0xcd }, 16},
+	{"DCX H", []byte{0x2b}, nil, func(s *cpuState) { s.L = 0x3f }, 5},
+	{"INR L", []byte{0x2c}, nil, func(s *cpuState) { s.L, s.F = 0x41, flagP }, 5},
+	{"DCR L", []byte{0x2d}, nil, func(s *cpuState) { s.L, s.F = 0x3f, flagP }, 5},
+	{"MVI L", []byte{0x2e, 0x55}, nil, func(s *cpuState) { s.L = 0x55 }, 7},
+	{"CMA", []byte{0x2f}, func(s *cpuState) { s.A, s.F = 0x51, flagZ|flagCY }, func(s *cpuState) { s.A = 0xae }, 4},
+
+	{"*NOP 30", []byte{0x30}, nil, nil, 4},
+	{"LXI SP", []byte{0x31, 0x21, 0x43}, nil, func(s *cpuState) { s.SP = 0x4321 }, 10},
+	{"STA", []byte{0x32, 0x00, 0x26}, func(s *cpuState) { s.A = 0x5a }, func(s *cpuState) { s.Mem[0x2600] = 0x5a }, 13},
+	{"INX SP", []byte{0x33}, nil, func(s *cpuState) { s.SP = 0x3001 }, 5},
+	{"INR M", []byte{0x34}, func(s *cpuState) { s.Mem[0x2040] = 0xff }, func(s *cpuState) { s.Mem[0x2040], s.F = 0x00, flagZ|flagP|flagAC }, 10},
+	{"DCR M", []byte{0x35}, func(s *cpuState) { s.Mem[0x2040] = 0x01 }, func(s *cpuState) { s.Mem[0x2040], s.F = 0x00, flagZ|flagP|flagAC }, 10},
+	{"MVI M", []byte{0x36, 0x99}, nil, func(s *cpuState) { s.Mem[0x2040] = 0x99 }, 10},
+	{"STC", []byte{0x37}, nil, func(s *cpuState) { s.F = flagCY }, 4},
+	{"*NOP 38", []byte{0x38}, nil, nil, 4},
+	{"DAD SP", []byte{0x39}, nil, func(s *cpuState) { s.H, s.L = 0x50, 0x40 }, 10},
+	{"LDA", []byte{0x3a, 0x00, 0x26}, func(s *cpuState) { s.Mem[0x2600] = 0x66 }, func(s *cpuState) { s.A = 0x66 }, 13},
+	{"DCX SP", []byte{0x3b}, nil, func(s *cpuState) { s.SP = 0x2fff }, 5},
+	{"INR A", []byte{0x3c}, nil, func(s *cpuState) { s.A = 0x02 }, 5},
+	{"DCR A", []byte{0x3d}, nil, func(s *cpuState) { s.A, s.F = 0x00, flagZ|flagP|flagAC }, 5},
+	{"MVI A", []byte{0x3e, 0xaa}, nil, func(s *cpuState) { s.A = 0xaa }, 7},
+	{"CMC", []byte{0x3f}, func(s *cpuState) { s.F = flagCY | flagS }, func(s *cpuState) { s.F = flagS }, 4},
+
+	// 0x40-0x7f: movTests, with HLT in the middle
+	{"HLT", []byte{0x76}, func(s *cpuState) { s.IntEnable = true }, func(s *cpuState) { s.Halted = true }, 7},
+
+	// 0x80-0xbf: aluTests
+
+	{"POP B", []byte{0xc1}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0x34, 0x12 }, func(s *cpuState) { s.B, s.C, s.SP = 0x12, 0x34, 0x3002 }, 10},
+	{"JMP", []byte{0xc3, 0x34, 0x12}, nil, func(s *cpuState) { s.PC = 0x1234 }, 10},
+	{"PUSH B", []byte{0xc5}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP = 0x02, 0x03, 0x2ffe }, 11},
+	{"ADI", []byte{0xc6, 0x94}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0x00, flagZ|flagAC|flagP|flagCY }, 7},
+	{"RST 0", []byte{0xc7}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0000 }, 11},
+	{"RET", []byte{0xc9}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0x34, 0x12 }, func(s *cpuState) { s.SP, s.PC = 0x3002, 0x1234 }, 10},
+	{"*JMP CB", []byte{0xcb, 0x34, 0x12}, nil, func(s *cpuState) { s.PC = 0x1234 }, 10},
+	{"CALL", []byte{0xcd, 0x34, 0x12}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x03, 0x2ffe, 0x1234 }, 17},
+	{"ACI", []byte{0xce, 0x93}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0x00, flagZ|flagAC|flagP|flagCY }, 7},
+	{"RST 1", []byte{0xcf}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0008 }, 11},
+
+	{"POP D", []byte{0xd1}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0x34, 0x12 }, func(s *cpuState) { s.D, s.E, s.SP = 0x12, 0x34, 0x3002 }, 10},
+	{"OUT", []byte{0xd3, 0x10}, func(s *cpuState) { s.A = 0x5a }, func(s *cpuState) { s.Ports[0x10] = 0x5a }, 10},
+	{"PUSH D", []byte{0xd5}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP = 0x04, 0x05, 0x2ffe }, 11},
+	{"SUI", []byte{0xd6, 0x6c}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0x00, flagZ|flagAC|flagP }, 7},
+	{"RST 2", []byte{0xd7}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0010 }, 11},
+	{"*RET D9", []byte{0xd9}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0x34, 0x12 }, func(s *cpuState) { s.SP, s.PC = 0x3002, 0x1234 }, 10},
+	{"IN", []byte{0xdb, 0x20}, func(s *cpuState) { s.Ports[0x20] = 0xa7 }, func(s *cpuState) { s.A = 0xa7 }, 10},
+	{"*CALL DD", []byte{0xdd, 0x34, 0x12}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x03, 0x2ffe, 0x1234 }, 17},
+	{"SBI", []byte{0xde, 0x6b}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0x00, flagZ|flagAC|flagP }, 7},
+	{"RST 3", []byte{0xdf}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0018 }, 11},
+
+	{"POP H", []byte{0xe1}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0x34, 0x12 }, func(s *cpuState) { s.H, s.L, s.SP = 0x12, 0x34, 0x3002 }, 10},
+	{"XTHL", []byte{0xe3}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0x34, 0x12 }, func(s *cpuState) { s.H, s.L, s.Mem[0x3000], s.Mem[0x3001] = 0x12, 0x34, 0x40, 0x20 }, 18},
+	{"PUSH H", []byte{0xe5}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP = 0x20, 0x40, 0x2ffe }, 11},
+	{"ANI", []byte{0xe6, 0x0f}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0x0c, flagAC|flagP }, 7},
+	{"RST 4", []byte{0xe7}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0020 }, 11},
+	{"PCHL", []byte{0xe9}, nil, func(s *cpuState) { s.PC = 0x2040 }, 5},
+	{"XCHG", []byte{0xeb}, nil, func(s *cpuState) { s.D, s.E, s.H, s.L = 0x20, 0x40, 0x04, 0x05 }, 4},
+	{"*CALL ED", []byte{0xed, 0x34, 0x12}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x03, 0x2ffe, 0x1234 }, 17},
+	{"XRI", []byte{0xee, 0xff}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0x93, flagS|flagP }, 7},
+	{"RST 5", []byte{0xef}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0028 }, 11},
+
+	{"POP PSW", []byte{0xf1}, func(s *cpuState) { s.Mem[0x3000], s.Mem[0x3001] = 0xff, 0x3c }, func(s *cpuState) { s.A, s.F, s.SP = 0x3c, flagS|flagZ|flagAC|flagP|flagCY, 0x3002 }, 10},
+	{"DI", []byte{0xf3}, func(s *cpuState) { s.IntEnable = true }, func(s *cpuState) { s.IntEnable = false }, 4},
+	{"PUSH PSW", []byte{0xf5}, func(s *cpuState) { s.F = flagS | flagZ | flagAC | flagP | flagCY }, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP = 0x01, 0xd7, 0x2ffe }, 11},
+	{"ORI", []byte{0xf6, 0x80}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.A, s.F = 0xec, flagS }, 7},
+	{"RST 6", []byte{0xf7}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0030 }, 11},
+	{"SPHL", []byte{0xf9}, nil, func(s *cpuState) { s.SP = 0x2040 }, 5},
+	{"EI", []byte{0xfb}, nil, func(s *cpuState) { s.IntEnable = true }, 4},
+	{"*CALL FD", []byte{0xfd, 0x34, 0x12}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x03, 0x2ffe, 0x1234 }, 17},
+	{"CPI", []byte{0xfe, 0x7a}, func(s *cpuState) { s.A, s.F = 0x6c, flagCY }, func(s *cpuState) { s.F = flagS | flagAC | flagCY }, 7},
+	{"RST 7", []byte{0xff}, nil, func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x01, 0x2ffe, 0x0038 }, 11},
+}
+
+// reg returns the register that operand field r of an opcode selects, in
+// the order B, C, D, E, H, L, M, A. It is nil for M.
+func reg(s *cpuState, r int) *uint8 {
+	return [8]*uint8{&s.B, &s.C, &s.D, &s.E, &s.H, &s.L, nil, &s.A}[r]
+}
+
+var regNames = [8]string{"B", "C", "D", "E", "H", "L", "M", "A"}
+
+// movTests covers MOV dst,src for every pair but M,M, which is HLT. M is
+// the byte at HL, 0x2040 in the initial state.
+func movTests() []opcodeTest {
+	var tests []opcodeTest
+	for dst := 0; dst < 8; dst++ {
+		for src := 0; src < 8; src++ {
+			dst, src := dst, src
+			if dst == 6 && src == 6 {
+				continue
+			}
+			tc := opcodeTest{
+				name:   fmt.Sprintf("MOV %s,%s", regNames[dst], regNames[src]),
+				code:   []byte{0x40 | uint8(dst<<3) | uint8(src)},
+				cycles: 5,
+			}
+			switch {
+			case src == 6:
+				tc.setup = func(s *cpuState) { s.Mem[0x2040] = 0x88 }
+				tc.want = func(s *cpuState) { *reg(s, dst) = 0x88 }
+				tc.cycles = 7
+			case dst == 6:
+				tc.want = func(s *cpuState) { s.Mem[0x2040] = *reg(s, src) }
+				tc.cycles = 7
+			default:
+				tc.want = func(s *cpuState) { *reg(s, dst) = *reg(s, src) }
+			}
+			tests = append(tests, tc)
+		}
+	}
+	return tests
+}
+
+// aluOps lists the results of the register arithmetic and logic
+// instructions with A = 0x6C and the carry set: first with an operand of
+// 0x2E, then with A as the operand.
+var aluOps = []struct {
+	name         string
+	opcode       uint8
+	a, f         uint8
+	selfA, selfF uint8
+}{
+	{"ADD", 0x80, 0x9a, flagS | flagAC | flagP, 0xd8, flagS | flagAC | flagP},
+	{"ADC", 0x88, 0x9b, flagS | flagAC, 0xd9, flagS | flagAC},
+	{"SUB", 0x90, 0x3e, 0, 0x00, flagZ | flagAC | flagP},
+	{"SBB", 0x98, 0x3d, 0, 0xff, flagS | flagP | flagCY},
+	{"ANA", 0xa0, 0x2c, flagAC, 0x6c, flagAC | flagP},
+	{"XRA", 0xa8, 0x42, flagP, 0x00, flagZ | flagP},
+	{"ORA", 0xb0, 0x6e, 0, 0x6c, flagP},
+	{"CMP", 0xb8, 0x6c, 0, 0x6c, flagZ | flagAC | flagP},
+}
+
+// aluTests covers the arithmetic and logic instructions with register and
+// memory operands.
+func aluTests() []opcodeTest {
+	var tests []opcodeTest
+	for _, op := range aluOps {
+		for src := 0; src < 8; src++ {
+			op, src := op, src
+			tc := opcodeTest{
+				name:   fmt.Sprintf("%s %s", op.name, regNames[src]),
+				code:   []byte{op.opcode | uint8(src)},
+				cycles: 4,
+			}
+			switch src {
+			case 7:
+				tc.setup = func(s *cpuState) { s.A, s.F = 0x6c, flagCY }
+				tc.want = func(s *cpuState) { s.A, s.F = op.selfA, op.selfF }
+			case 6:
+				tc.setup = func(s *cpuState) { s.A, s.F, s.Mem[0x2040] = 0x6c, flagCY, 0x2e }
+				tc.want = func(s *cpuState) { s.A, s.F = op.a, op.f }
+				tc.cycles = 7
+			default:
+				tc.setup = func(s *cpuState) { s.A, s.F, *reg(s, src) = 0x6c, flagCY, 0x2e }
+				tc.want = func(s *cpuState) { s.A, s.F = op.a, op.f }
+			}
+			tests = append(tests, tc)
+		}
+	}
+	return tests
+}
+
+// conditions lists the branch conditions in opcode order with flags that
+// make each one true and false.
+var conditions = []struct {
+	name        string
+	true, false uint8
+}{
+	{"NZ", 0, flagZ},
+	{"Z", flagZ, 0},
+	{"NC", 0, flagCY},
+	{"C", flagCY, 0},
+	{"PO", 0, flagP},
+	{"PE", flagP, 0},
+	{"P", 0, flagS},
+	{"M", flagS, 0},
+}
+
+// branchTests covers the conditional RET, JMP and CALL instructions, each
+// with the condition true and false.
+func branchTests() []opcodeTest {
+	var tests []opcodeTest
+	for i, cond := range conditions {
+		cond := cond
+		ret, jmp, call := 0xc0|uint8(i<<3), 0xc2|uint8(i<<3), 0xc4|uint8(i<<3)
+		tests = append(tests,
+			opcodeTest{"R" + cond.name + " taken", []byte{ret},
+				func(s *cpuState) { s.F, s.Mem[0x3000], s.Mem[0x3001] = cond.true, 0x34, 0x12 },
+				func(s *cpuState) { s.SP, s.PC = 0x3002, 0x1234 }, 11},
+			opcodeTest{"R" + cond.name + " not taken", []byte{ret},
+				func(s *cpuState) { s.F, s.Mem[0x3000], s.Mem[0x3001] = cond.false, 0x34, 0x12 },
+				nil, 5},
+			opcodeTest{"J" + cond.name + " taken", []byte{jmp, 0x34, 0x12},
+				func(s *cpuState) { s.F = cond.true },
+				func(s *cpuState) { s.PC = 0x1234 }, 10},
+			opcodeTest{"J" + cond.name + " not taken", []byte{jmp, 0x34, 0x12},
+				func(s *cpuState) { s.F = cond.false },
+				nil, 10},
+			opcodeTest{"C" + cond.name + " taken", []byte{call, 0x34, 0x12},
+				func(s *cpuState) { s.F = cond.true },
+				func(s *cpuState) { s.Mem[0x2fff], s.Mem[0x2ffe], s.SP, s.PC = 0x01, 0x03, 0x2ffe, 0x1234 }, 17},
+			opcodeTest{"C" + cond.name + " not taken", []byte{call, 0x34, 0x12},
+				func(s *cpuState) { s.F = cond.false },
+				nil, 11},
+		)
+	}
+	return tests
+}
+
+func allOpcodeTests() []opcodeTest {
+	tests := append([]opcodeTest(nil), opcodeTests...)
+	tests = append(tests, movTests()...)
+	tests = append(tests, aluTests()...)
+	return append(tests, branchTests()...)
+}
+
+func TestOpcodes(t *testing.T) {
+	for _, tc := range allOpcodeTests() {
+		t.Run(fmt.Sprintf("%02X %s", tc.code[0], tc.name), tc.run)
+	}
+}
+
+func TestOpcodesCovered(t *testing.T) {
+	var covered [256]bool
+	for _, tc := range allOpcodeTests() {
+		covered[tc.code[0]] = true
+	}
+	for opcode, ok := range covered {
+		if !ok {
+			t.Errorf("no test for opcode %02X", opcode)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package cpu8080
+
+import "fmt"
+
+// BC returns the B and C registers as a 16-bit pair.
+func (state *State8080) BC() uint16 {
+	return (uint16(state.B) << 8) | uint16(state.C)
+}
+
+// DE returns the D and E registers as a 16-bit pair.
+func (state *State8080) DE() uint16 {
+	return (uint16(state.D) << 8) | uint16(state.E)
+}
+
+// HL returns the H and L registers as a 16-bit pair.
+func (state *State8080) HL() uint16 {
+	return (uint16(state.H) << 8) | uint16(state.L)
+}
+
+// PSW returns A and the packed flag byte as pushed by PUSH PSW.
+func (state *State8080) PSW() uint16 {
+	return (uint16(state.A) << 8) | uint16(state.Cc.PSW())
+}
+
+// SetBC loads the B and C registers from a 16-bit value.
+func (state *State8080) SetBC(value uint16) {
+	state.B = uint8(value >> 8)
+	state.C = uint8(value & 0xff)
+}
+
+// SetDE loads the D and E registers from a 16-bit value.
+func (state *State8080) SetDE(value uint16) {
+	state.D = uint8(value >> 8)
+	state.E = uint8(value & 0xff)
+}
+
+// SetHL loads the H and L registers from a 16-bit value.
+func (state *State8080) SetHL(value uint16) {
+	state.H = uint8(value >> 8)
+	state.L = uint8(value & 0xff)
+}
+
+// SetPSW loads A and the flags from a 16-bit value as popped by POP PSW.
+func (state *State8080) SetPSW(value uint16) {
+	state.A = uint8(value >> 8)
+	state.Cc.SetPSW(uint8(value & 0xff))
+}
+
+// Registers is a snapshot of the CPU registers.
+type Registers struct {
+	A, B, C, D, E, H, L uint8
+	SP, PC              uint16
+	Cc                  ConditionCodes
+	IntEnable           bool
+}
+
+// Registers returns a snapshot of the current register contents.
+func (state *State8080) Registers() Registers {
+	return Registers{
+		A: state.A, B: state.B, C: state.C, D: state.D,
+		E: state.E, H: state.H, L: state.L,
+		SP: state.SP, PC: state.PC,
+		Cc:        state.Cc,
+		IntEnable: state.IntEnable,
+	}
+}
+
+func (r Registers) String() string {
+	return fmt.Sprintf("A=%02X F=%02X B=%02X C=%02X D=%02X E=%02X H=%02X L=%02X SP=%04X PC=%04X",
+		r.A, r.Cc.PSW(), r.B, r.C, r.D, r.E, r.H, r.L, r.SP, r.PC)
+}
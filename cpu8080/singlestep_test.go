@@ -0,0 +1,266 @@
+package cpu8080
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// singleStepDir holds per-opcode test vectors in the format of the
+// SingleStepTests project, one file per opcode such as 3c.json. They are not
+// distributed with the emulator; copy them there to run them.
+var singleStepDir = filepath.Join("testdata", "8080")
+
+// maxReported is the number of failing cases reported in full for each
+// opcode file; the rest are only counted.
+const maxReported = 5
+
+// stepCase is one test vector: the machine state before and after a single
+// instruction and the bus cycles it took.
+type stepCase struct {
+	Name    string            `json:"name"`
+	Initial stepState         `json:"initial"`
+	Final   stepState         `json:"final"`
+	Cycles  []json.RawMessage `json:"cycles"` // one entry per T-state
+	Ports   []stepPort        `json:"ports"`
+}
+
+type stepState struct {
+	PC  uint16      `json:"pc"`
+	SP  uint16      `json:"sp"`
+	A   uint8       `json:"a"`
+	B   uint8       `json:"b"`
+	C   uint8       `json:"c"`
+	D   uint8       `json:"d"`
+	E   uint8       `json:"e"`
+	F   uint8       `json:"f"`
+	H   uint8       `json:"h"`
+	L   uint8       `json:"l"`
+	RAM [][2]uint16 `json:"ram"` // address and value pairs
+}
+
+// stepPort is a port access made by the instruction, written in the vectors
+// as [port, value, "r"] or [port, value, "w"].
+type stepPort struct {
+	Port  uint8
+	Value uint8
+	Write bool
+}
+
+func (p *stepPort) UnmarshalJSON(data []byte) error {
+	var fields []interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("port access %s: want [port, value, direction]", data)
+	}
+	port, ok1 := fields[0].(float64)
+	value, ok2 := fields[1].(float64)
+	dir, ok3 := fields[2].(string)
+	if !ok1 || !ok2 || !ok3 || (dir != "r" && dir != "w") {
+		return fmt.Errorf("port access %s: want [port, value, \"r\" or \"w\"]", data)
+	}
+	// the port number appears on both halves of the address bus
+	p.Port, p.Value, p.Write = uint8(port), uint8(value), dir == "w"
+	return nil
+}
+
+// loadStepCases reads the test vectors in the file at path.
+func loadStepCases(path string) ([]stepCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []stepCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// image returns the 64 KiB of memory described by s on top of base.
+func (s stepState) image(base RAM) RAM {
+	ram := append(RAM(nil), base...)
+	for _, pair := range s.RAM {
+		ram[pair[0]] = uint8(pair[1])
+	}
+	return ram
+}
+
+// run executes the instruction of c and returns a line for each way the
+// result differs from the final state. It returns nil if the CPU got it
+// right.
+func (c stepCase) run() []string {
+	ram := c.Initial.image(NewRAM(0x10000))
+	want := c.Final.image(ram)
+
+	state := NewState8080WithBus(ram)
+	in := c.Initial
+	state.A, state.B, state.C, state.D, state.E, state.H, state.L = in.A, in.B, in.C, in.D, in.E, in.H, in.L
+	state.SP, state.PC = in.SP, in.PC
+	state.Cc.SetPSW(in.F)
+	ports := &testPorts{in: map[uint8]uint8{}, out: map[uint8]uint8{}}
+	for _, p := range c.Ports {
+		if !p.Write {
+			ports.in[p.Port] = p.Value
+		}
+	}
+	state.IO = ports
+
+	var diffs []string
+	cycles, err := state.Step()
+	if err != nil && !errors.Is(err, ErrHalted) {
+		diffs = append(diffs, fmt.Sprintf("error: %v", err))
+	}
+
+	out := c.Final
+	reg16 := func(name string, got, want uint16) {
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("%s: got %04X, want %04X", name, got, want))
+		}
+	}
+	reg8 := func(name string, got, want uint8) {
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("%s: got %02X, want %02X", name, got, want))
+		}
+	}
+	reg16("pc", state.PC, out.PC)
+	reg16("sp", state.SP, out.SP)
+	reg8("a", state.A, out.A)
+	reg8("b", state.B, out.B)
+	reg8("c", state.C, out.C)
+	reg8("d", state.D, out.D)
+	reg8("e", state.E, out.E)
+	reg8("h", state.H, out.H)
+	reg8("l", state.L, out.L)
+	if f := state.Cc.PSW(); f != out.F {
+		diffs = append(diffs, fmt.Sprintf("f: got %02X %s, want %02X %s", f, flagString(f), out.F, flagString(out.F)))
+	}
+	for addr := range ram {
+		if ram[addr] != want[addr] {
+			diffs = append(diffs, fmt.Sprintf("memory[%04X]: got %02X, want %02X", addr, ram[addr], want[addr]))
+		}
+	}
+	for _, p := range c.Ports {
+		if !p.Write {
+			continue
+		}
+		if got, ok := ports.out[p.Port]; !ok || got != p.Value {
+			diffs = append(diffs, fmt.Sprintf("port %02X: got %02X (written %v), want %02X", p.Port, got, ok, p.Value))
+		}
+		delete(ports.out, p.Port)
+	}
+	for port, value := range ports.out {
+		diffs = append(diffs, fmt.Sprintf("port %02X: unexpected write of %02X", port, value))
+	}
+	if cycles != len(c.Cycles) {
+		diffs = append(diffs, fmt.Sprintf("cycles: got %d, want %d", cycles, len(c.Cycles)))
+	}
+	return diffs
+}
+
+// flagString shows the bits of PSW flags f from bit 7 down, the set ones by
+// name and the clear ones as dots, such as "SZ.A.P1C".
+func flagString(f uint8) string {
+	names := [8]string{"C", "1", "P", "-", "A", "-", "Z", "S"}
+	var b strings.Builder
+	for bit := 7; bit >= 0; bit-- {
+		if f&(1<<bit) != 0 && names[bit] != "-" {
+			b.WriteString(names[bit])
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+func TestSingleStep(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(singleStepDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skipf("no test vectors in %s", singleStepDir)
+	}
+	for _, path := range paths {
+		path := path
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			t.Parallel()
+			cases, err := loadStepCases(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			failed := 0
+			for _, c := range cases {
+				diffs := c.run()
+				if diffs == nil {
+					continue
+				}
+				failed++
+				if failed <= maxReported {
+					t.Errorf("%s:\n\t%s", c.Name, strings.Join(diffs, "\n\t"))
+				}
+			}
+			if failed > 0 {
+				t.Errorf("%d of %d cases failed", failed, len(cases))
+			}
+		})
+	}
+}
+
+// stepSample holds vectors for INR A and OUT in the format of the published
+// files, so that the loader is tested even when those are absent.
+const stepSample = `[{
+	"name": "3c 0000",
+	"initial": {"pc": 4096, "sp": 8192, "a": 15, "b": 1, "c": 2, "d": 3, "e": 4, "f": 3, "h": 5, "l": 6,
+		"ram": [[4096, 60]]},
+	"final": {"pc": 4097, "sp": 8192, "a": 16, "b": 1, "c": 2, "d": 3, "e": 4, "f": 19, "h": 5, "l": 6,
+		"ram": [[4096, 60]]},
+	"cycles": [[4096, 60, "read"], [4096, 60, "read"], [4096, 60, "read"], [4096, 60, "read"], [null, null, ""]]
+}, {
+	"name": "d3 0000",
+	"initial": {"pc": 4096, "sp": 8192, "a": 90, "b": 1, "c": 2, "d": 3, "e": 4, "f": 2, "h": 5, "l": 6,
+		"ram": [[4096, 211], [4097, 16]]},
+	"final": {"pc": 4098, "sp": 8192, "a": 90, "b": 1, "c": 2, "d": 3, "e": 4, "f": 2, "h": 5, "l": 6,
+		"ram": [[4096, 211], [4097, 16]]},
+	"cycles": [[], [], [], [], [], [], [], [], [], []],
+	"ports": [[4112, 90, "w"]]
+}]`
+
+func TestSingleStepLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.json")
+	if err := os.WriteFile(path, []byte(stepSample), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases, err := loadStepCases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 2 {
+		t.Fatalf("loaded %d cases, want 2", len(cases))
+	}
+	for _, c := range cases {
+		if diffs := c.run(); diffs != nil {
+			t.Errorf("%s:\n\t%s", c.Name, strings.Join(diffs, "\n\t"))
+		}
+	}
+
+	// a wrong final state is reported register by register
+	c := cases[0]
+	c.Final.A, c.Final.F = 0x11, 0x02
+	c.Final.RAM = append(c.Final.RAM, [2]uint16{0x2000, 0xff})
+	diffs := c.run()
+	want := []string{
+		"a: got 10, want 11",
+		"f: got 13 ...A..1C, want 02 ......1.",
+		"memory[2000]: got 00, want FF",
+	}
+	if strings.Join(diffs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffs:\n%s\nwant:\n%s", strings.Join(diffs, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,37 @@
+package cpu8080
+
+// read16 returns the little-endian word at address.
+func (state *State8080) read16(address uint16) uint16 {
+	return (uint16(state.read(address+1)) << 8) | uint16(state.read(address))
+}
+
+// operand returns the 16-bit operand of the instruction at PC.
+func (state *State8080) operand() uint16 {
+	return state.read16(state.PC + 1)
+}
+
+// push pushes value onto the stack, high byte first (PUSH).
+func (state *State8080) push(value uint16) {
+	state.write(state.SP-1, uint8(value>>8))
+	state.write(state.SP-2, uint8(value&0xff))
+	state.SP -= 2
+}
+
+// pop pops a value off the stack (POP).
+func (state *State8080) pop() uint16 {
+	value := state.read16(state.SP)
+	state.SP += 2
+	return value
+}
+
+// call pushes PC, which the caller has already moved past the instruction,
+// and jumps to adr (CALL, RST).
+func (state *State8080) call(adr uint16) {
+	state.push(state.PC)
+	state.PC = adr
+}
+
+// ret returns to the address on top of the stack (RET).
+func (state *State8080) ret() {
+	state.PC = state.pop()
+}
@@ -0,0 +1,73 @@
+package invaders
+
+// Button is a cabinet control wired to one of the input ports.
+type Button int
+
+const (
+	Coin Button = iota
+	Start1
+	Start2
+	Fire1
+	Left1
+	Right1
+	Fire2
+	Left2
+	Right2
+	Tilt
+)
+
+// buttonBits gives the input port and bit each button drives.
+var buttonBits = [...]struct {
+	port uint8
+	mask uint8
+}{
+	Coin:   {1, 0x01},
+	Start2: {1, 0x02},
+	Start1: {1, 0x04},
+	Fire1:  {1, 0x10},
+	Left1:  {1, 0x20},
+	Right1: {1, 0x40},
+	Tilt:   {2, 0x04},
+	Fire2:  {2, 0x10},
+	Left2:  {2, 0x20},
+	Right2: {2, 0x40},
+}
+
+// DIPSwitches holds the operator settings read from port 2.
+type DIPSwitches struct {
+	Ships        int  // lives per game, 3 to 6
+	ExtraShipLow bool // award the extra ship at 1000 points instead of 1500
+	HideCoinInfo bool // hide the coin information in attract mode
+}
+
+// DefaultDIPSwitches is the factory setting: three ships, extra ship at 1500.
+var DefaultDIPSwitches = DIPSwitches{Ships: 3}
+
+// bits returns the port 2 bits driven by the switches.
+func (d DIPSwitches) bits() uint8 {
+	var value uint8
+	if d.Ships > 3 {
+		value |= uint8(d.Ships-3) & 0x03
+	}
+	if d.ExtraShipLow {
+		value |= 0x08
+	}
+	if d.HideCoinInfo {
+		value |= 0x80
+	}
+	return value
+}
+
+// inputs tracks the state of the cabinet controls.
+type inputs struct {
+	port [3]uint8
+}
+
+func (in *inputs) set(b Button, pressed bool) {
+	bit := buttonBits[b]
+	if pressed {
+		in.port[bit.port] |= bit.mask
+	} else {
+		in.port[bit.port] &^= bit.mask
+	}
+}
@@ -0,0 +1,188 @@
+// Package invaders emulates the Midway/Taito Space Invaders arcade board
+// around a cpu8080 core.
+package invaders
+
+import (
+	"fmt"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+	"github.com/PetrusJPrinsloo/8080Emulator/sched"
+)
+
+const (
+	// ClockHz is the CPU clock of the Space Invaders board.
+	ClockHz = 1996800
+	// FrameRate is the refresh rate of the monitor.
+	FrameRate = 60
+
+	// ROMSize is the size of the program ROM mapped at 0x0000.
+	ROMSize = 0x2000
+	// RAMStart is where the work and video RAM begins.
+	RAMStart = 0x2000
+	// RAMSize is the size of the work and video RAM.
+	RAMSize = 0x2000
+
+	// WatchdogFrames is how many frames the game may go without writing to
+	// the watchdog on port 6 before the board resets the CPU. The game
+	// writes it every frame; 255 frames, about 4.25 seconds, is the timeout
+	// MAME's mw8080bw driver uses for this board family.
+	WatchdogFrames = 255
+)
+
+// Machine is a Space Invaders board: CPU, memory map, shift register,
+// controls and the interrupts raised by the video hardware.
+type Machine struct {
+	CPU    *cpu8080.State8080
+	Memory *cpu8080.MemoryMap
+	RAM    cpu8080.RAM
+	Ports  *cpu8080.Ports
+	DIP    DIPSwitches
+	Sound  SoundDevice // receives writes to the sound ports; may be nil
+
+	// Scheduler runs the CPU frame by frame and raises the video
+	// interrupts. Clear its Throttle to run faster than real time.
+	Scheduler *sched.Scheduler
+
+	// Frame counts completed video frames.
+	Frame uint64
+	// WatchdogResets counts the times the watchdog has reset the CPU.
+	WatchdogResets int
+
+	video     video
+	shift     ShiftRegister
+	inputs    inputs
+	watchdog  int
+	sound     [2]uint8 // last values written to ports 3 and 5
+	cycleBase uint64   // CPU cycles run before the last reset
+}
+
+// New builds a machine running rom, which must be the 8 KiB program image
+// (invaders.h, .g, .f and .e in that order).
+func New(rom []byte) (*Machine, error) {
+	if len(rom) > ROMSize {
+		return nil, fmt.Errorf("invaders: ROM is %d bytes, want at most %d", len(rom), ROMSize)
+	}
+	m := &Machine{DIP: DefaultDIPSwitches}
+	m.SetOverlay(Monochrome)
+
+	// The board decodes 15 address lines: ROM at 0x0000, RAM at 0x2000
+	// mirrored at 0x6000, nothing at 0x4000, and the whole 32 KiB repeated
+	// at 0x8000.
+	m.Memory = cpu8080.NewMemoryMap()
+	image := make([]byte, ROMSize)
+	copy(image, rom)
+	m.Memory.MapROM(0x0000, image)
+	m.RAM = m.Memory.MapRAM(RAMStart, RAMSize)
+	m.Memory.Mirror(0x6000, RAMSize, RAMStart, RAMSize)
+	m.Memory.Mirror(0x8000, 0x8000, 0x0000, 0x8000)
+
+	m.Ports = cpu8080.NewPorts()
+	m.Ports.Attach(m, 0, 1, 2, 3, 4, 5, 6)
+
+	m.CPU = cpu8080.NewState8080WithBus(m.Memory)
+	m.CPU.IO = m.Ports
+	m.SetClock(ClockHz)
+	return m, nil
+}
+
+// SetClock changes the CPU clock to hz, keeping the frame rate, so that the
+// game runs faster or slower than the real board. The video interrupts stay
+// at the middle and end of each frame.
+func (m *Machine) SetClock(hz int) {
+	s := sched.New(m.CPU, hz, FrameRate)
+	if m.Scheduler != nil {
+		s.Throttle = m.Scheduler.Throttle
+	}
+	// The video hardware raises RST 1 when the beam reaches the middle of
+	// the screen and RST 2 at vertical blank.
+	s.At(s.CyclesPerFrame()/2, func() { m.CPU.Interrupt(cpu8080.RST1) })
+	s.At(s.CyclesPerFrame(), func() { m.CPU.Interrupt(cpu8080.RST2) })
+	m.Scheduler = s
+}
+
+// Reset restarts the CPU and clears the board's latches. RAM is kept, as on
+// the real board.
+func (m *Machine) Reset() {
+	m.cycleBase += m.CPU.Cycles
+	m.CPU.Reset()
+	m.shift = ShiftRegister{}
+	m.watchdog = 0
+	m.sound = [2]uint8{}
+}
+
+// SetButton presses or releases a cabinet control.
+func (m *Machine) SetButton(b Button, pressed bool) {
+	m.inputs.set(b, pressed)
+}
+
+// SoundLatches returns the last values written to sound ports 3 and 5.
+func (m *Machine) SoundLatches() (port3, port5 uint8) {
+	return m.sound[0], m.sound[1]
+}
+
+// Cycles returns the number of CPU cycles run since the machine was built,
+// including those before any reset.
+func (m *Machine) Cycles() uint64 {
+	return m.cycleBase + m.CPU.Cycles
+}
+
+// RunFrame runs the CPU for one video frame, raising the video interrupts on
+// the way. It does not wait for real time to catch up; call Scheduler.Sync
+// for that.
+func (m *Machine) RunFrame() error {
+	if err := m.Scheduler.RunFrame(); err != nil {
+		return err
+	}
+	m.Frame++
+
+	if m.Sound != nil {
+		if err := m.Sound.Advance(m.Cycles()); err != nil {
+			return err
+		}
+	}
+
+	m.watchdog++
+	if m.watchdog > WatchdogFrames {
+		m.WatchdogResets++
+		m.Reset()
+	}
+	return nil
+}
+
+// In implements cpu8080.Device for the board's input ports.
+func (m *Machine) In(port uint8) uint8 {
+	switch port {
+	case 0:
+		// Bits 1-3 are tied high; the player 1 controls are also wired here.
+		return 0x0e | (m.inputs.port[1] & 0x70)
+	case 1:
+		return 0x08 | m.inputs.port[1]
+	case 2:
+		return m.DIP.bits() | m.inputs.port[2]
+	case 3:
+		return m.shift.Read()
+	}
+	return 0
+}
+
+// Out implements cpu8080.Device for the board's output ports.
+func (m *Machine) Out(port uint8, value uint8) {
+	switch port {
+	case 2:
+		m.shift.SetOffset(value)
+	case 3:
+		m.sound[0] = value
+		if m.Sound != nil {
+			m.Sound.SoundWrite(port, value, m.Cycles())
+		}
+	case 4:
+		m.shift.Write(value)
+	case 5:
+		m.sound[1] = value
+		if m.Sound != nil {
+			m.Sound.SoundWrite(port, value, m.Cycles())
+		}
+	case 6:
+		m.watchdog = 0
+	}
+}
@@ -0,0 +1,147 @@
+package invaders
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/cpu8080"
+)
+
+func newMachine(t *testing.T, program ...byte) *Machine {
+	t.Helper()
+	m, err := New(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestShiftRegister(t *testing.T) {
+	tests := []struct {
+		writes []uint8
+		offset uint8
+		want   uint8
+	}{
+		{[]uint8{0xaa, 0xff}, 0, 0xff},
+		{[]uint8{0xaa, 0xff}, 3, 0xfd},
+		{[]uint8{0xaa, 0xff}, 7, 0xd5},
+		{[]uint8{0xaa, 0xff}, 0x0b, 0xfd}, // only the low three bits count
+		{[]uint8{0x12, 0x34, 0x56}, 4, 0x63},
+		{[]uint8{0x81}, 1, 0x02},
+	}
+	for _, tc := range tests {
+		m := newMachine(t)
+		for _, v := range tc.writes {
+			m.Out(4, v)
+		}
+		m.Out(2, tc.offset)
+		if got := m.In(3); got != tc.want {
+			t.Errorf("writes %02X, offset %d: IN 3 = %02X, want %02X", tc.writes, tc.offset, got, tc.want)
+		}
+	}
+}
+
+func TestShiftRegisterFromCPU(t *testing.T) {
+	m := newMachine(t,
+		0x3e, 0xaa, // MVI A,AA
+		0xd3, 0x04, // OUT 4
+		0x3e, 0xff, // MVI A,FF
+		0xd3, 0x04, // OUT 4
+		0x3e, 0x03, // MVI A,3
+		0xd3, 0x02, // OUT 2
+		0xdb, 0x03, // IN 3
+		0x76, // HLT
+	)
+	for {
+		if _, err := m.CPU.Step(); err != nil {
+			if !errors.Is(err, cpu8080.ErrHalted) {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	if m.CPU.A != 0xfd {
+		t.Errorf("A = %02X after IN 3, want FD", m.CPU.A)
+	}
+}
+
+func TestInputPorts(t *testing.T) {
+	tests := []struct {
+		button           Button
+		port0, port1, p2 uint8
+	}{
+		{Coin, 0x0e, 0x09, 0x00},
+		{Start2, 0x0e, 0x0a, 0x00},
+		{Start1, 0x0e, 0x0c, 0x00},
+		{Fire1, 0x1e, 0x18, 0x00},
+		{Left1, 0x2e, 0x28, 0x00},
+		{Right1, 0x4e, 0x48, 0x00},
+		{Tilt, 0x0e, 0x08, 0x04},
+		{Fire2, 0x0e, 0x08, 0x10},
+		{Left2, 0x0e, 0x08, 0x20},
+		{Right2, 0x0e, 0x08, 0x40},
+	}
+	for _, tc := range tests {
+		m := newMachine(t)
+		m.SetButton(tc.button, true)
+		if p0, p1, p2 := m.In(0), m.In(1), m.In(2); p0 != tc.port0 || p1 != tc.port1 || p2 != tc.p2 {
+			t.Errorf("button %d pressed: ports %02X %02X %02X, want %02X %02X %02X", tc.button, p0, p1, p2, tc.port0, tc.port1, tc.p2)
+		}
+		m.SetButton(tc.button, false)
+		if p0, p1, p2 := m.In(0), m.In(1), m.In(2); p0 != 0x0e || p1 != 0x08 || p2 != 0x00 {
+			t.Errorf("button %d released: ports %02X %02X %02X, want 0E 08 00", tc.button, p0, p1, p2)
+		}
+	}
+}
+
+func TestDIPSwitches(t *testing.T) {
+	tests := []struct {
+		dip  DIPSwitches
+		want uint8
+	}{
+		{DefaultDIPSwitches, 0x00},
+		{DIPSwitches{Ships: 4}, 0x01},
+		{DIPSwitches{Ships: 5}, 0x02},
+		{DIPSwitches{Ships: 6}, 0x03},
+		{DIPSwitches{Ships: 3, ExtraShipLow: true}, 0x08},
+		{DIPSwitches{Ships: 3, HideCoinInfo: true}, 0x80},
+		{DIPSwitches{Ships: 6, ExtraShipLow: true, HideCoinInfo: true}, 0x8b},
+	}
+	for _, tc := range tests {
+		m := newMachine(t)
+		m.DIP = tc.dip
+		m.SetButton(Fire2, true)
+		if got := m.In(2); got != tc.want|0x10 {
+			t.Errorf("%+v: IN 2 = %02X, want %02X", tc.dip, got, tc.want|0x10)
+		}
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	loop := []byte{0xc3, 0x00, 0x00}             // JMP 0000
+	kick := []byte{0xd3, 0x06, 0xc3, 0x00, 0x00} // OUT 6; JMP 0000
+	for _, tc := range []struct {
+		name    string
+		program []byte
+		resets  int
+	}{
+		{"silent", loop, 1},
+		{"kicked", kick, 0},
+	} {
+		m := newMachine(t, tc.program...)
+		for i := 0; i < WatchdogFrames; i++ {
+			if err := m.RunFrame(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if m.WatchdogResets != 0 {
+			t.Fatalf("%s: watchdog reset the CPU within %d frames", tc.name, WatchdogFrames)
+		}
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+		if m.WatchdogResets != tc.resets {
+			t.Errorf("%s: %d watchdog resets after %d frames, want %d", tc.name, m.WatchdogResets, WatchdogFrames+1, tc.resets)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package invaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Band is a rectangle of coloured gel over the picture, in the rotated
+// ScreenWidth x ScreenHeight coordinates. Lit pixels inside it take its
+// colour. A zero Right means the band runs to the right edge of the screen.
+type Band struct {
+	Top    int        `json:"top"`
+	Bottom int        `json:"bottom"`
+	Left   int        `json:"left"`
+	Right  int        `json:"right"`
+	Color  color.RGBA `json:"-"`
+}
+
+// Overlay gives the colours of the picture: the unlit background, the colour
+// of lit pixels and the gel bands laid over them.
+type Overlay struct {
+	Background color.RGBA
+	Foreground color.RGBA
+	Bands      []Band
+}
+
+var (
+	black = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red   = color.RGBA{0xff, 0x20, 0x20, 0xff}
+	green = color.RGBA{0x20, 0xff, 0x20, 0xff}
+)
+
+// Monochrome is the bare black and white monitor.
+var Monochrome = Overlay{Background: black, Foreground: white}
+
+// Authentic approximates the gel strips of an upright cabinet: red across the
+// flying saucer's row, green across the shields and the player's cannon, and
+// green over the reserve cannons in the bottom left.
+var Authentic = Overlay{
+	Background: black,
+	Foreground: white,
+	Bands: []Band{
+		{Top: 32, Bottom: 64, Color: red},
+		{Top: 184, Bottom: 240, Color: green},
+		{Top: 240, Bottom: 256, Left: 16, Right: 134, Color: green},
+	},
+}
+
+// Overlays lists the built-in overlays by name.
+var Overlays = map[string]Overlay{
+	"mono":      Monochrome,
+	"authentic": Authentic,
+	"green":     {Background: black, Foreground: color.RGBA{0x33, 0xff, 0x66, 0xff}},
+	"amber":     {Background: black, Foreground: color.RGBA{0xff, 0xb0, 0x00, 0xff}},
+}
+
+// OverlayNames returns the names of the built-in overlays in sorted order.
+func OverlayNames() []string {
+	var names []string
+	for name := range Overlays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Palette returns the overlay's colours as a palette: the background at index
+// 0, the foreground at index 1 and then one entry per band.
+func (o Overlay) Palette() color.Palette {
+	palette := color.Palette{o.Background, o.Foreground}
+	for _, band := range o.Bands {
+		palette = append(palette, band.Color)
+	}
+	return palette
+}
+
+// indexMap returns the palette index of a lit pixel for every position on the
+// screen. Later bands are laid over earlier ones.
+func (o Overlay) indexMap() []uint8 {
+	indices := make([]uint8, ScreenWidth*ScreenHeight)
+	for i := range indices {
+		indices[i] = 1
+	}
+	for b, band := range o.Bands {
+		right := band.Right
+		if right == 0 {
+			right = ScreenWidth
+		}
+		for y := band.Top; y < band.Bottom && y < ScreenHeight; y++ {
+			for x := band.Left; x < right && x < ScreenWidth; x++ {
+				if x >= 0 && y >= 0 {
+					indices[y*ScreenWidth+x] = uint8(2 + b)
+				}
+			}
+		}
+	}
+	return indices
+}
+
+// overlayFile is the JSON form of an Overlay, with colours as #rrggbb.
+type overlayFile struct {
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+	Bands      []struct {
+		Band
+		Color string `json:"color"`
+	} `json:"bands"`
+}
+
+// ReadOverlay reads an overlay from JSON such as
+//
+//	{"background": "#000000", "foreground": "#ffffff",
+//	 "bands": [{"top": 32, "bottom": 64, "color": "#ff2020"}]}
+func ReadOverlay(r io.Reader) (Overlay, error) {
+	var file overlayFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return Overlay{}, fmt.Errorf("invaders: reading overlay: %w", err)
+	}
+
+	overlay := Monochrome
+	var err error
+	if file.Background != "" {
+		if overlay.Background, err = parseColor(file.Background); err != nil {
+			return Overlay{}, err
+		}
+	}
+	if file.Foreground != "" {
+		if overlay.Foreground, err = parseColor(file.Foreground); err != nil {
+			return Overlay{}, err
+		}
+	}
+	for _, b := range file.Bands {
+		band := b.Band
+		if band.Color, err = parseColor(b.Color); err != nil {
+			return Overlay{}, err
+		}
+		overlay.Bands = append(overlay.Bands, band)
+	}
+	if len(overlay.Bands) > 254 {
+		return Overlay{}, fmt.Errorf("invaders: overlay has %d bands, at most 254 are allowed", len(overlay.Bands))
+	}
+	return overlay, nil
+}
+
+// LoadOverlay returns the built-in overlay called name, or reads one from the
+// JSON file at that path.
+func LoadOverlay(name string) (Overlay, error) {
+	if overlay, ok := Overlays[name]; ok {
+		return overlay, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return Overlay{}, fmt.Errorf("invaders: unknown overlay %q (built in: %s)", name, strings.Join(OverlayNames(), ", "))
+	}
+	defer file.Close()
+	return ReadOverlay(file)
+}
+
+// parseColor parses a #rrggbb colour.
+func parseColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+	if len(s) != 7 || s[0] != '#' {
+		return c, fmt.Errorf("invaders: colour %q is not in #rrggbb form", s)
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invaders: colour %q is not in #rrggbb form", s)
+	}
+	c.A = 0xff
+	return c, nil
+}
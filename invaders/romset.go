@@ -0,0 +1,194 @@
+package invaders
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Chip is one ROM chip of a set: the file holding it, where it sits in the
+// address space and what it should contain.
+type Chip struct {
+	Name    string
+	Address uint16
+	Size    int
+	CRC32   uint32
+}
+
+// ROMSet is a program image made up of several chips.
+type ROMSet struct {
+	Name  string
+	Chips []Chip
+}
+
+// Invaders is the Midway Space Invaders program, split over four 2 KiB chips.
+var Invaders = ROMSet{
+	Name: "invaders",
+	Chips: []Chip{
+		{Name: "invaders.h", Address: 0x0000, Size: 0x0800, CRC32: 0x734f5ad8},
+		{Name: "invaders.g", Address: 0x0800, Size: 0x0800, CRC32: 0x6bfaca4a},
+		{Name: "invaders.f", Address: 0x1000, Size: 0x0800, CRC32: 0x0ccead96},
+		{Name: "invaders.e", Address: 0x1800, Size: 0x0800, CRC32: 0x14e538b0},
+	},
+}
+
+var (
+	// ErrChipMissing means a chip's file was not found.
+	ErrChipMissing = errors.New("not found")
+	// ErrChipSize means a chip's file is the wrong size.
+	ErrChipSize = errors.New("wrong size")
+	// ErrChipChecksum means a chip's contents do not match its CRC32, as with
+	// a bad dump or a modified program.
+	ErrChipChecksum = errors.New("checksum mismatch")
+)
+
+// ChipError describes a problem with one chip of a set.
+type ChipError struct {
+	Chip   Chip
+	Err    error // ErrChipMissing, ErrChipSize, ErrChipChecksum or a read error
+	Detail string
+}
+
+func (e *ChipError) Error() string {
+	msg := fmt.Sprintf("%s at 0x%04x: %v", e.Chip.Name, e.Chip.Address, e.Err)
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg
+}
+
+func (e *ChipError) Unwrap() error {
+	return e.Err
+}
+
+// ROMSetError lists every chip of a set that could not be loaded or
+// verified. It matches an Err of any of its chips with errors.Is.
+type ROMSetError struct {
+	Set   string
+	Chips []*ChipError
+}
+
+func (e *ROMSetError) Error() string {
+	var msgs []string
+	for _, c := range e.Chips {
+		msgs = append(msgs, c.Error())
+	}
+	return fmt.Sprintf("invaders: ROM set %s: %s", e.Set, strings.Join(msgs, "; "))
+}
+
+func (e *ROMSetError) Is(target error) bool {
+	for _, c := range e.Chips {
+		if errors.Is(c, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChecksumOnly reports whether every problem is a checksum mismatch, in
+// which case the image is complete but not the one the set describes.
+func (e *ROMSetError) ChecksumOnly() bool {
+	for _, c := range e.Chips {
+		if c.Err != ErrChipChecksum {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the size of the image the set makes: up to the end of its
+// highest chip.
+func (s ROMSet) Size() int {
+	size := 0
+	for _, c := range s.Chips {
+		if end := int(c.Address) + c.Size; end > size {
+			size = end
+		}
+	}
+	return size
+}
+
+// Load reads the set's chips from path, which is either a directory or a
+// zip archive, and returns the combined image. See LoadFS.
+func (s ROMSet) Load(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return s.LoadFS(os.DirFS(path))
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("invaders: %s is neither a directory nor a zip archive: %w", path, err)
+	}
+	defer archive.Close()
+	return s.LoadFS(archive)
+}
+
+// LoadFS reads the set's chips from the top level of fsys, matching file
+// names without regard to case, and returns the combined image. Missing,
+// unreadable and wrongly sized chips are reported together in a
+// *ROMSetError. If the only problems are checksum mismatches, the image is
+// returned along with the error so that the caller may choose to use it.
+func (s ROMSet) LoadFS(fsys fs.FS) ([]byte, error) {
+	names := map[string]string{}
+	if entries, err := fs.ReadDir(fsys, "."); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names[strings.ToLower(entry.Name())] = entry.Name()
+			}
+		}
+	}
+
+	image := make([]byte, s.Size())
+	setErr := &ROMSetError{Set: s.Name}
+	for _, chip := range s.Chips {
+		name, ok := names[strings.ToLower(chip.Name)]
+		if !ok {
+			setErr.Chips = append(setErr.Chips, &ChipError{Chip: chip, Err: ErrChipMissing})
+			continue
+		}
+		data, err := readChip(fsys, name, chip.Size)
+		switch {
+		case err != nil:
+			setErr.Chips = append(setErr.Chips, &ChipError{Chip: chip, Err: err})
+		case len(data) > chip.Size:
+			setErr.Chips = append(setErr.Chips, &ChipError{Chip: chip, Err: ErrChipSize,
+				Detail: fmt.Sprintf("more than %d bytes", chip.Size)})
+		case len(data) < chip.Size:
+			setErr.Chips = append(setErr.Chips, &ChipError{Chip: chip, Err: ErrChipSize,
+				Detail: fmt.Sprintf("%d bytes, want %d", len(data), chip.Size)})
+		default:
+			copy(image[chip.Address:], data)
+			if sum := crc32.ChecksumIEEE(data); sum != chip.CRC32 {
+				setErr.Chips = append(setErr.Chips, &ChipError{Chip: chip, Err: ErrChipChecksum,
+					Detail: fmt.Sprintf("CRC32 %08x, want %08x", sum, chip.CRC32)})
+			}
+		}
+	}
+
+	switch {
+	case len(setErr.Chips) == 0:
+		return image, nil
+	case setErr.ChecksumOnly():
+		return image, setErr
+	}
+	return nil, setErr
+}
+
+// readChip reads the file called name, stopping one byte past size so that an
+// oversized file is caught without reading it all.
+func readChip(fsys fs.FS, name string, size int) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, int64(size)+1))
+}
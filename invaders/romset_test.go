@@ -0,0 +1,160 @@
+package invaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// chipData returns size bytes of recognisable contents for a test chip.
+func chipData(seed byte, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = seed + byte(i)
+	}
+	return data
+}
+
+// testSet is a two-chip set whose checksums match chipData.
+var testSet = ROMSet{
+	Name: "test",
+	Chips: []Chip{
+		{Name: "test.a", Address: 0x0000, Size: 0x10, CRC32: crc32.ChecksumIEEE(chipData(0x10, 0x10))},
+		{Name: "test.b", Address: 0x0010, Size: 0x10, CRC32: crc32.ChecksumIEEE(chipData(0x80, 0x10))},
+	},
+}
+
+func goodFS() fstest.MapFS {
+	return fstest.MapFS{
+		"test.a": {Data: chipData(0x10, 0x10)},
+		"test.b": {Data: chipData(0x80, 0x10)},
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	want := append(chipData(0x10, 0x10), chipData(0x80, 0x10)...)
+	tests := []struct {
+		name     string
+		edit     func(fstest.MapFS)
+		errs     []error // errors the result must match, in chip order
+		image    bool    // whether the image comes back
+		checksum bool    // whether ChecksumOnly should report true
+	}{
+		{"complete", func(fstest.MapFS) {}, nil, true, false},
+		{"names in any case", func(fsys fstest.MapFS) {
+			fsys["TEST.B"] = fsys["test.b"]
+			delete(fsys, "test.b")
+		}, nil, true, false},
+		{"missing", func(fsys fstest.MapFS) { delete(fsys, "test.a") }, []error{ErrChipMissing}, false, false},
+		{"short", func(fsys fstest.MapFS) { fsys["test.b"].Data = chipData(0x80, 0x0f) }, []error{ErrChipSize}, false, false},
+		{"oversized", func(fsys fstest.MapFS) { fsys["test.a"].Data = chipData(0x10, 0x11) }, []error{ErrChipSize}, false, false},
+		{"bad checksum", func(fsys fstest.MapFS) { fsys["test.b"].Data = chipData(0x81, 0x10) }, []error{ErrChipChecksum}, true, true},
+		{"missing and bad checksum", func(fsys fstest.MapFS) {
+			fsys["test.a"].Data = chipData(0x11, 0x10)
+			delete(fsys, "test.b")
+		}, []error{ErrChipChecksum, ErrChipMissing}, false, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := goodFS()
+			tc.edit(fsys)
+			image, err := testSet.LoadFS(fsys)
+
+			if tc.image != (image != nil) {
+				t.Errorf("image returned: %v, want %v", image != nil, tc.image)
+			}
+			if image != nil && tc.errs == nil && !bytes.Equal(image, want) {
+				t.Errorf("image = % x, want % x", image, want)
+			}
+			if tc.errs == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var setErr *ROMSetError
+			if !errors.As(err, &setErr) {
+				t.Fatalf("error %v is not a *ROMSetError", err)
+			}
+			if len(setErr.Chips) != len(tc.errs) {
+				t.Fatalf("%d chip errors, want %d: %v", len(setErr.Chips), len(tc.errs), err)
+			}
+			for i, want := range tc.errs {
+				if !errors.Is(setErr.Chips[i], want) || !errors.Is(err, want) {
+					t.Errorf("chip error %d = %v, want %v", i, setErr.Chips[i], want)
+				}
+			}
+			if setErr.ChecksumOnly() != tc.checksum {
+				t.Errorf("ChecksumOnly = %v, want %v", setErr.ChecksumOnly(), tc.checksum)
+			}
+		})
+	}
+}
+
+// zipSet returns a zip archive of the files in fsys.
+func zipSet(t *testing.T, fsys fstest.MapFS) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, file := range fsys {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(file.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	set := filepath.Join(dir, "set")
+	if err := os.Mkdir(set, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, file := range goodFS() {
+		if err := os.WriteFile(filepath.Join(set, name), file.Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(dir, "set.zip")
+	if err := os.WriteFile(archive, zipSet(t, goodFS()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	notZip := filepath.Join(dir, "set.rom")
+	if err := os.WriteFile(notZip, []byte("not a zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(chipData(0x10, 0x10), chipData(0x80, 0x10)...)
+	for _, path := range []string{set, archive} {
+		image, err := testSet.Load(path)
+		if err != nil {
+			t.Errorf("%s: %v", filepath.Base(path), err)
+		} else if !bytes.Equal(image, want) {
+			t.Errorf("%s: image = % x, want % x", filepath.Base(path), image, want)
+		}
+	}
+	if _, err := testSet.Load(notZip); err == nil {
+		t.Errorf("loaded a set from a file that is not a zip archive")
+	}
+
+	bad := goodFS()
+	delete(bad, "test.a")
+	if err := os.WriteFile(archive, zipSet(t, bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testSet.Load(archive); !errors.Is(err, ErrChipMissing) {
+		t.Errorf("zip without test.a: error %v, want ErrChipMissing", err)
+	}
+}
@@ -0,0 +1,25 @@
+package invaders
+
+// ShiftRegister is the dedicated 16-bit barrel shifter the game uses to draw
+// sprites at arbitrary pixel offsets. OUT 4 shifts a byte in from the top,
+// OUT 2 sets the offset, and IN 3 reads 8 bits starting at that offset.
+type ShiftRegister struct {
+	value  uint16
+	offset uint8
+}
+
+// Write shifts value into the high byte, moving the old high byte down.
+func (s *ShiftRegister) Write(value uint8) {
+	s.value = (uint16(value) << 8) | (s.value >> 8)
+}
+
+// SetOffset sets the read offset from the low three bits of value.
+func (s *ShiftRegister) SetOffset(value uint8) {
+	s.offset = value & 0x07
+}
+
+// Read returns the 8 bits that start offset bits below the top of the
+// register.
+func (s *ShiftRegister) Read() uint8 {
+	return uint8(s.value >> (8 - s.offset))
+}
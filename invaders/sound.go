@@ -0,0 +1,229 @@
+package invaders
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/PetrusJPrinsloo/8080Emulator/audio"
+)
+
+// Effect is one of the sound effects on the Space Invaders sound board,
+// numbered like the files of the standard sample pack (0.wav to 9.wav).
+type Effect int
+
+const (
+	UFO Effect = iota
+	Shot
+	PlayerDeath
+	InvaderDeath
+	Fleet1
+	Fleet2
+	Fleet3
+	Fleet4
+	UFOHit
+	ExtraLife
+
+	numEffects
+)
+
+// effectBits gives the output port and bit that trigger each effect.
+var effectBits = [numEffects]struct {
+	port uint8
+	mask uint8
+}{
+	UFO:          {3, 0x01},
+	Shot:         {3, 0x02},
+	PlayerDeath:  {3, 0x04},
+	InvaderDeath: {3, 0x08},
+	ExtraLife:    {3, 0x10},
+	Fleet1:       {5, 0x01},
+	Fleet2:       {5, 0x02},
+	Fleet3:       {5, 0x04},
+	Fleet4:       {5, 0x08},
+	UFOHit:       {5, 0x10},
+}
+
+// ampEnable is the port 3 bit that switches the sound amplifier on.
+const ampEnable = 0x20
+
+// SoundDevice receives the writes to sound ports 3 and 5, stamped with the
+// machine time in CPU cycles, and is advanced at the end of every frame.
+type SoundDevice interface {
+	SoundWrite(port uint8, value uint8, cycle uint64)
+	Advance(cycle uint64) error
+}
+
+// Voices produce the audio of the effects.
+type Voices interface {
+	// Start begins an effect when its bit goes high.
+	Start(e Effect)
+	// Stop is called when an effect's bit goes low. Only the UFO, which
+	// sounds for as long as its bit is held, needs to act on it.
+	Stop(e Effect)
+	// Mix adds the next len(buf) samples of every sounding effect to buf.
+	Mix(buf []float32)
+}
+
+// SoundBoard turns writes to the sound ports into effects and renders the
+// mixed result, in step with emulated time, to an audio writer.
+type SoundBoard struct {
+	// ClockHz is the CPU clock the write cycles are counted in. It must
+	// match the machine's if that has been changed with SetClock.
+	ClockHz int
+
+	voices Voices
+	out    audio.Writer
+	rate   int
+
+	latch    [2]uint8 // ports 3 and 5
+	rendered uint64   // samples written so far
+	buf      []float32
+	err      error
+}
+
+// NewSoundBoard returns a sound board playing voices into out at rate
+// samples per second.
+func NewSoundBoard(voices Voices, out audio.Writer, rate int) *SoundBoard {
+	return &SoundBoard{ClockHz: ClockHz, voices: voices, out: out, rate: rate}
+}
+
+// SoundWrite renders audio up to cycle, then starts and stops effects for the
+// bits that changed.
+func (s *SoundBoard) SoundWrite(port uint8, value uint8, cycle uint64) {
+	s.render(cycle)
+	latch := &s.latch[0]
+	if port == 5 {
+		latch = &s.latch[1]
+	}
+	changed := *latch ^ value
+	*latch = value
+	for e := Effect(0); e < numEffects; e++ {
+		bit := effectBits[e]
+		if bit.port != port || changed&bit.mask == 0 {
+			continue
+		}
+		if value&bit.mask != 0 {
+			s.voices.Start(e)
+		} else {
+			s.voices.Stop(e)
+		}
+	}
+}
+
+// Advance renders audio up to cycle and reports any error from the writer.
+func (s *SoundBoard) Advance(cycle uint64) error {
+	s.render(cycle)
+	return s.err
+}
+
+// render writes the samples between the last render and cycle. The sound is
+// silent while the amplifier is switched off.
+func (s *SoundBoard) render(cycle uint64) {
+	target := cycle * uint64(s.rate) / uint64(s.ClockHz)
+	if target <= s.rendered || s.err != nil {
+		return
+	}
+	n := int(target - s.rendered)
+	if cap(s.buf) < n {
+		s.buf = make([]float32, n)
+	}
+	buf := s.buf[:n]
+	for i := range buf {
+		buf[i] = 0
+	}
+	s.voices.Mix(buf)
+	if s.latch[0]&ampEnable == 0 {
+		for i := range buf {
+			buf[i] = 0
+		}
+	}
+	s.err = s.out.Write(buf)
+	s.rendered = target
+}
+
+// Close flushes the audio writer.
+func (s *SoundBoard) Close() error {
+	if err := s.out.Close(); err != nil {
+		return err
+	}
+	return s.err
+}
+
+// sampleGain scales each sample so that several effects can sound at once
+// without clipping.
+const sampleGain = 0.5
+
+// SamplePlayer plays each effect from a recorded sample.
+type SamplePlayer struct {
+	clips   [numEffects]*audio.Clip
+	playing [numEffects]int // next sample of each effect, or -1 when silent
+	loop    [numEffects]bool
+}
+
+// LoadSamples reads the sample pack in dir, resampled to rate. Files 0.wav to
+// 9.wav hold the effects in Effect order; missing files leave their effect
+// silent, but at least one must exist.
+func LoadSamples(dir string, rate int) (*SamplePlayer, error) {
+	p := &SamplePlayer{}
+	found := 0
+	for e := Effect(0); e < numEffects; e++ {
+		p.playing[e] = -1
+		path := filepath.Join(dir, fmt.Sprintf("%d.wav", e))
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		clip, err := audio.ReadWAV(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		p.clips[e] = clip.Resample(rate)
+		found++
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("invaders: no samples (0.wav to 9.wav) in %s", dir)
+	}
+	return p, nil
+}
+
+func (p *SamplePlayer) Start(e Effect) {
+	if p.clips[e] == nil {
+		return
+	}
+	p.playing[e] = 0
+	p.loop[e] = e == UFO
+}
+
+func (p *SamplePlayer) Stop(e Effect) {
+	if p.loop[e] {
+		p.playing[e] = -1
+		p.loop[e] = false
+	}
+}
+
+func (p *SamplePlayer) Mix(buf []float32) {
+	for e, clip := range p.clips {
+		pos := p.playing[e]
+		if clip == nil || pos < 0 || len(clip.Samples) == 0 {
+			continue
+		}
+		for i := range buf {
+			if pos >= len(clip.Samples) {
+				if !p.loop[e] {
+					pos = -1
+					break
+				}
+				pos = 0
+			}
+			buf[i] += clip.Samples[pos] * sampleGain
+			pos++
+		}
+		p.playing[e] = pos
+	}
+}
@@ -0,0 +1,106 @@
+package invaders
+
+import (
+	"fmt"
+	"testing"
+)
+
+// recordVoices logs starts and stops, and sounds at full level while any
+// effect is started.
+type recordVoices struct {
+	log      []string
+	sounding int
+}
+
+func (v *recordVoices) Start(e Effect) {
+	v.log = append(v.log, fmt.Sprintf("start %d", e))
+	v.sounding++
+}
+
+func (v *recordVoices) Stop(e Effect) {
+	v.log = append(v.log, fmt.Sprintf("stop %d", e))
+	v.sounding--
+}
+
+func (v *recordVoices) Mix(buf []float32) {
+	if v.sounding > 0 {
+		for i := range buf {
+			buf[i] += 1
+		}
+	}
+}
+
+// sampleBuffer collects the samples written to it.
+type sampleBuffer struct {
+	samples []float32
+	closed  bool
+}
+
+func (b *sampleBuffer) Write(samples []float32) error {
+	b.samples = append(b.samples, samples...)
+	return nil
+}
+
+func (b *sampleBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestSoundBoardSampleCount(t *testing.T) {
+	out := &sampleBuffer{}
+	s := NewSoundBoard(&recordVoices{}, out, 48000)
+	// one frame at a time for a second, as RunFrame advances it
+	cycles := uint64(ClockHz / FrameRate)
+	for frame := uint64(1); frame <= FrameRate; frame++ {
+		if err := s.Advance(frame * cycles); err != nil {
+			t.Fatal(err)
+		}
+		if want := int(frame * cycles * 48000 / ClockHz); len(out.samples) != want {
+			t.Fatalf("frame %d: %d samples, want %d", frame, len(out.samples), want)
+		}
+	}
+	// time that does not reach the next sample writes nothing
+	s.Advance(FrameRate*cycles + 1)
+	if want := 48000; len(out.samples) != want {
+		t.Errorf("%d samples after a second, want %d", len(out.samples), want)
+	}
+	if err := s.Close(); err != nil || !out.closed {
+		t.Errorf("Close: %v, writer closed %v", err, out.closed)
+	}
+}
+
+func TestSoundBoardWrites(t *testing.T) {
+	voices := &recordVoices{}
+	out := &sampleBuffer{}
+	s := NewSoundBoard(voices, out, 1000)
+	s.ClockHz = 1000 // one sample per cycle
+
+	s.SoundWrite(3, effectBits[Shot].mask, 10) // amplifier off
+	s.SoundWrite(3, ampEnable|effectBits[Shot].mask, 20)
+	s.SoundWrite(3, ampEnable, 30)
+	s.SoundWrite(5, effectBits[Fleet1].mask|effectBits[UFOHit].mask, 40)
+	s.SoundWrite(5, effectBits[UFOHit].mask, 50)
+	s.SoundWrite(3, 0, 60)
+	if err := s.Advance(70); err != nil {
+		t.Fatal(err)
+	}
+
+	wantLog := []string{"start 1", "stop 1", "start 4", "start 8", "stop 4"}
+	if fmt.Sprint(voices.log) != fmt.Sprint(wantLog) {
+		t.Errorf("voices %v, want %v", voices.log, wantLog)
+	}
+	if len(out.samples) != 70 {
+		t.Fatalf("%d samples, want 70", len(out.samples))
+	}
+	for i, sample := range out.samples {
+		// the shot sounds from 10 but the amplifier is off until 20;
+		// effects sound from 40 until the amplifier goes off at 60
+		want := float32(0)
+		if i >= 20 && i < 30 || i >= 40 && i < 60 {
+			want = 1
+		}
+		if sample < want-0.001 || sample > want+0.001 {
+			t.Errorf("sample %d = %v, want %v", i, sample, want)
+		}
+	}
+}
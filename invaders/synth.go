@@ -0,0 +1,146 @@
+package invaders
+
+import "math"
+
+// synthGain is the level of each synthesized effect, matching sampleGain.
+const synthGain = 0.5
+
+// fleetNotes are the pitches, in Hz, of the four-note fleet march.
+var fleetNotes = [4]float64{110.0, 98.0, 92.5, 82.4}
+
+// synthVoice is the running state of one synthesized effect.
+type synthVoice struct {
+	active bool
+	t      int     // samples since the effect started
+	phase  float64 // oscillator phase in cycles
+	filter float32 // low-pass filter state for noise
+}
+
+// Synth approximates the discrete analogue circuits of the sound board, for
+// use when no sample pack is available: filtered noise for the explosions
+// and shot, a swept tone for the flying saucer and square-wave notes for the
+// fleet march.
+type Synth struct {
+	rate   float64
+	voices [numEffects]synthVoice
+	noise  uint32 // 17-bit LFSR noise generator
+}
+
+// NewSynth returns a synthesizer producing rate samples per second.
+func NewSynth(rate int) *Synth {
+	return &Synth{rate: float64(rate), noise: 1}
+}
+
+func (s *Synth) Start(e Effect) {
+	s.voices[e] = synthVoice{active: true}
+}
+
+func (s *Synth) Stop(e Effect) {
+	if e == UFO {
+		s.voices[e].active = false
+	}
+}
+
+func (s *Synth) Mix(buf []float32) {
+	for e := range s.voices {
+		v := &s.voices[e]
+		for i := range buf {
+			if !v.active {
+				break
+			}
+			buf[i] += s.sample(Effect(e), v) * synthGain
+			v.t++
+		}
+	}
+}
+
+// nextNoise steps the noise generator and returns -1 or 1.
+func (s *Synth) nextNoise() float32 {
+	bit := (s.noise ^ (s.noise >> 3)) & 1
+	s.noise = (s.noise >> 1) | (bit << 16)
+	if s.noise&1 != 0 {
+		return 1
+	}
+	return -1
+}
+
+// oscillate advances v's phase at freq Hz and returns the new phase.
+func (s *Synth) oscillate(v *synthVoice, freq float64) float64 {
+	v.phase += freq / s.rate
+	v.phase -= math.Floor(v.phase)
+	return v.phase
+}
+
+// lowPass filters x through v's one-pole filter with coefficient k (0 to 1,
+// higher is brighter).
+func lowPass(v *synthVoice, x float32, k float32) float32 {
+	v.filter += (x - v.filter) * k
+	return v.filter
+}
+
+func square(phase float64) float32 {
+	if phase < 0.5 {
+		return 1
+	}
+	return -1
+}
+
+func triangle(phase float64) float64 {
+	return 4*math.Abs(phase-0.5) - 1
+}
+
+// sample returns the next sample of effect e and deactivates v when the
+// effect has finished.
+func (s *Synth) sample(e Effect, v *synthVoice) float32 {
+	t := float64(v.t) / s.rate
+	var out float32
+	var length float64
+
+	switch e {
+	case UFO:
+		// A tone swept up and down by a slow triangle, held as long as
+		// the bit is set.
+		freq := 700 + 300*triangle(math.Mod(t*4, 1))
+		out = float32(triangle(s.oscillate(v, freq))) * 0.6
+		length = math.Inf(1)
+	case Shot:
+		// A falling tone over a burst of noise.
+		length = 0.3
+		freq := 1200 - 3000*t
+		env := float32(1 - t/length)
+		out = (square(s.oscillate(v, freq))*0.4 + lowPass(v, s.nextNoise(), 0.3)*0.6) * env
+	case PlayerDeath:
+		// A long, dull explosion.
+		length = 1.0
+		out = lowPass(v, s.nextNoise(), 0.08) * float32(math.Exp(-3*t)) * 2
+	case InvaderDeath:
+		// A short, bright explosion.
+		length = 0.25
+		out = lowPass(v, s.nextNoise(), 0.4) * float32(math.Exp(-12*t))
+	case Fleet1, Fleet2, Fleet3, Fleet4:
+		// One note of the march: a low square wave thumping off quickly.
+		length = 0.12
+		freq := fleetNotes[e-Fleet1]
+		out = square(s.oscillate(v, freq)) * float32(math.Exp(-20*t)) * 0.8
+	case UFOHit:
+		// A warble between two pitches, fading out.
+		length = 1.0
+		freq := 400.0
+		if math.Mod(t*16, 1) < 0.5 {
+			freq = 800
+		}
+		out = square(s.oscillate(v, freq)) * float32(1-t/length) * 0.5
+	case ExtraLife:
+		// A high tone chopped into beeps.
+		length = 1.0
+		if square(math.Mod(t*8, 1)) > 0 {
+			out = square(s.oscillate(v, 1200)) * 0.3
+		}
+	}
+
+	if t >= length {
+		v.active = false
+		return 0
+	}
+	return out
+}
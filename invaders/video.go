@@ -0,0 +1,89 @@
+package invaders
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ScreenWidth and ScreenHeight are the size of the picture as seen in the
+	// cabinet, where the monitor is mounted rotated 90 degrees.
+	ScreenWidth  = 224
+	ScreenHeight = 256
+
+	// VideoRAMStart is the address of the 1-bit frame buffer.
+	VideoRAMStart = 0x2400
+	// VideoRAMSize is the size of the frame buffer in bytes.
+	VideoRAMSize = ScreenWidth * ScreenHeight / 8
+)
+
+// video holds the colouring of the decoded screen.
+type video struct {
+	overlay Overlay
+	palette color.Palette
+	indices []uint8 // palette index of each screen position when lit
+}
+
+// SetOverlay changes the colours used by Screen and everything built on it.
+func (m *Machine) SetOverlay(o Overlay) {
+	m.video = video{overlay: o, palette: o.Palette(), indices: o.indexMap()}
+}
+
+// Overlay returns the overlay in use.
+func (m *Machine) Overlay() Overlay {
+	return m.video.overlay
+}
+
+// VideoRAM returns the frame buffer. Each run of 32 bytes is one scan line of
+// the unrotated monitor, least significant bit first.
+func (m *Machine) VideoRAM() []byte {
+	start := VideoRAMStart - RAMStart
+	return m.RAM[start : start+VideoRAMSize]
+}
+
+// Screen decodes the frame buffer into a ScreenWidth x ScreenHeight image
+// rotated the way the cabinet shows it and coloured by the overlay. Palette
+// index 0 is an unlit pixel; every other index is lit.
+func (m *Machine) Screen() *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, ScreenWidth, ScreenHeight), m.video.palette)
+	for i, b := range m.VideoRAM() {
+		if b == 0 {
+			continue
+		}
+		// Scan line i/32 of the monitor becomes column x of the picture;
+		// the monitor's left edge becomes the bottom.
+		x := i / 32
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				y := ScreenHeight - 1 - ((i%32)*8 + bit)
+				img.Pix[y*img.Stride+x] = m.video.indices[y*ScreenWidth+x]
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG encodes the current screen as a PNG.
+func (m *Machine) WritePNG(w io.Writer) error {
+	return png.Encode(w, m.Screen())
+}
+
+// Screenshot writes the current screen to a PNG file. Each %d in path is
+// replaced by the current frame number; the rest of path is used as given.
+func (m *Machine) Screenshot(path string) error {
+	path = strings.ReplaceAll(path, "%d", strconv.FormatUint(m.Frame, 10))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.WritePNG(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
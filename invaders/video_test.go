@@ -0,0 +1,71 @@
+package invaders
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScreen(t *testing.T) {
+	tests := []struct {
+		offset int
+		bit    uint
+		x, y   int
+	}{
+		{0, 0, 0, 255},      // start of the first scan line: bottom left
+		{0, 7, 0, 248},      // bits run up the screen
+		{1, 0, 0, 247},      // and so do bytes within a scan line
+		{31, 7, 0, 0},       // end of the first scan line: top left
+		{32, 0, 1, 255},     // the next scan line is the next column
+		{0x1bff, 7, 223, 0}, // last byte: top right
+		{0x1be0, 0, 223, 255},
+	}
+	for _, tc := range tests {
+		m := newMachine(t)
+		m.VideoRAM()[tc.offset] = 1 << tc.bit
+		img := m.Screen()
+		lit := 0
+		for i, index := range img.Pix {
+			if index == 0 {
+				continue
+			}
+			lit++
+			if x, y := i%img.Stride, i/img.Stride; x != tc.x || y != tc.y {
+				t.Errorf("byte %04X bit %d lit (%d, %d), want (%d, %d)", tc.offset, tc.bit, x, y, tc.x, tc.y)
+			}
+		}
+		if lit != 1 {
+			t.Errorf("byte %04X bit %d lit %d pixels, want 1", tc.offset, tc.bit, lit)
+		}
+	}
+}
+
+func TestScreenshotPath(t *testing.T) {
+	dir := t.TempDir()
+	m := newMachine(t)
+	m.Frame = 42
+	for path, want := range map[string]string{
+		"shot-%d.png":     "shot-42.png",
+		"shot-%d-50%.png": "shot-42-50%.png",
+		"%s-%d-%d.png":    "%s-42-42.png",
+		"plain.png":       "plain.png",
+	} {
+		if err := m.Screenshot(filepath.Join(dir, path)); err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		file, err := os.Open(filepath.Join(dir, want))
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		img, err := png.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Errorf("%s: %v", want, err)
+		} else if size := img.Bounds().Size(); size.X != ScreenWidth || size.Y != ScreenHeight {
+			t.Errorf("%s: %v image, want %dx%d", want, size, ScreenWidth, ScreenHeight)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package sched runs an emulated CPU one video frame at a time, firing
+// events at exact cycle positions within each frame and pacing the frames to
+// the wall clock.
+package sched
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultClockHz is the CPU clock used when none is given.
+const DefaultClockHz = 2000000
+
+// maxLag is how far behind the wall clock the scheduler may fall before it
+// gives up catching up and starts pacing afresh from the current time.
+const maxLag = 250 * time.Millisecond
+
+// CPU is anything that executes one instruction at a time and reports the
+// cycles it took, such as *cpu8080.State8080.
+type CPU interface {
+	Step() (int, error)
+}
+
+type event struct {
+	cycle int
+	fire  func()
+}
+
+// Scheduler runs a CPU in frames of a fixed number of cycles.
+type Scheduler struct {
+	// Throttle paces frames to real time in Sync. Turn it off to run as
+	// fast as possible, as tests and benchmarks do.
+	Throttle bool
+
+	// Frame counts completed frames.
+	Frame uint64
+
+	cpu            CPU
+	clockHz        int
+	cyclesPerFrame int
+	frameDuration  time.Duration // wall-clock length of a frame
+	events         []event
+	position       int // cycles into the current frame
+
+	start  time.Time // wall-clock time pacing started
+	synced int64     // frames paced since start
+}
+
+// New returns a throttled scheduler running cpu at clockHz with frameRate
+// frames per second. A clockHz of 0 selects DefaultClockHz.
+func New(cpu CPU, clockHz, frameRate int) *Scheduler {
+	if clockHz == 0 {
+		clockHz = DefaultClockHz
+	}
+	cyclesPerFrame := clockHz / frameRate
+	return &Scheduler{
+		Throttle:       true,
+		cpu:            cpu,
+		clockHz:        clockHz,
+		cyclesPerFrame: cyclesPerFrame,
+		frameDuration:  time.Duration(int64(cyclesPerFrame) * int64(time.Second) / int64(clockHz)),
+	}
+}
+
+// ClockHz returns the emulated CPU clock.
+func (s *Scheduler) ClockHz() int {
+	return s.clockHz
+}
+
+// CyclesPerFrame returns the number of cycles in each frame.
+func (s *Scheduler) CyclesPerFrame() int {
+	return s.cyclesPerFrame
+}
+
+// At schedules fire to run in every frame once cycle cycles of the frame have
+// elapsed. An event at CyclesPerFrame fires at the very end of the frame.
+// Events at the same cycle fire in the order they were added.
+func (s *Scheduler) At(cycle int, fire func()) {
+	s.events = append(s.events, event{cycle: cycle, fire: fire})
+	sort.SliceStable(s.events, func(i, j int) bool {
+		return s.events[i].cycle < s.events[j].cycle
+	})
+}
+
+// run steps the CPU until the frame reaches cycle.
+func (s *Scheduler) run(cycle int) error {
+	for s.position < cycle {
+		n, err := s.cpu.Step()
+		s.position += n
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunFrame runs the CPU for one frame, firing each event as soon as the
+// instruction that crosses its cycle completes. Cycles that overrun the end
+// of the frame count towards the next one, so frames average out exactly.
+func (s *Scheduler) RunFrame() error {
+	for _, e := range s.events {
+		if err := s.run(e.cycle); err != nil {
+			return err
+		}
+		e.fire()
+	}
+	if err := s.run(s.cyclesPerFrame); err != nil {
+		return err
+	}
+	s.position -= s.cyclesPerFrame
+	s.Frame++
+	return nil
+}
+
+// Sync waits until the wall-clock time at which the frame just run should
+// end. Deadlines are measured from when pacing started rather than from the
+// previous frame, so sleep overshoot does not accumulate into drift. If
+// emulation falls more than a quarter second behind, pacing restarts from
+// now instead of racing to catch up. Sync returns at once when Throttle is
+// off.
+func (s *Scheduler) Sync() {
+	if !s.Throttle {
+		s.start = time.Time{}
+		return
+	}
+	now := time.Now()
+	if s.start.IsZero() {
+		s.start = now
+		s.synced = 0
+	}
+	s.synced++
+	elapsed := time.Duration(s.synced) * s.frameDuration
+	wait := s.start.Add(elapsed).Sub(now)
+	if wait > 0 {
+		time.Sleep(wait)
+	} else if -wait > maxLag {
+		s.start = now
+		s.synced = 0
+	}
+}
@@ -0,0 +1,151 @@
+package sched
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeCPU takes the same number of cycles for every instruction and counts
+// the cycles it has run.
+type fakeCPU struct {
+	cycles int
+	total  int
+	err    error // returned once total reaches errAt
+	errAt  int
+}
+
+func (c *fakeCPU) Step() (int, error) {
+	c.total += c.cycles
+	if c.err != nil && c.total >= c.errAt {
+		return c.cycles, c.err
+	}
+	return c.cycles, nil
+}
+
+func TestEvents(t *testing.T) {
+	cpu := &fakeCPU{cycles: 7}
+	s := New(cpu, 6000, 60)
+	if s.CyclesPerFrame() != 100 {
+		t.Fatalf("CyclesPerFrame = %d, want 100", s.CyclesPerFrame())
+	}
+
+	type firing struct {
+		name  string
+		total int
+	}
+	var fired []firing
+	at := func(cycle int, name string) {
+		s.At(cycle, func() { fired = append(fired, firing{name, cpu.total}) })
+	}
+	// added out of order; "end" and "end 2" share a cycle
+	at(100, "end")
+	at(50, "middle")
+	at(0, "start")
+	at(100, "end 2")
+
+	const frames = 5
+	var want []firing
+	total := 0
+	for frame := 0; frame < frames; frame++ {
+		base := frame * 100
+		// an event fires after the first instruction that reaches its
+		// cycle, counting from where the frame started
+		reach := func(cycle int) int {
+			for total-base < cycle {
+				total += 7
+			}
+			return total
+		}
+		want = append(want, firing{"start", reach(0)}, firing{"middle", reach(50)},
+			firing{"end", reach(100)}, firing{"end 2", reach(100)})
+	}
+
+	for i := 0; i < frames; i++ {
+		if err := s.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(fired) != len(want) {
+		t.Fatalf("%d events fired, want %d: %v", len(fired), len(want), fired)
+	}
+	for i := range want {
+		if fired[i] != want[i] {
+			t.Errorf("event %d: %v, want %v", i, fired[i], want[i])
+		}
+	}
+	if s.Frame != frames {
+		t.Errorf("Frame = %d, want %d", s.Frame, frames)
+	}
+	// the cycles past each frame's end carry into the next
+	if want := cpu.total - frames*100; s.position != want || want < 0 || want >= 7 {
+		t.Errorf("position = %d after %d cycles, want %d", s.position, cpu.total, want)
+	}
+}
+
+func TestOverrunCarries(t *testing.T) {
+	// 300-cycle instructions overrun 100-cycle frames, so after the first
+	// frame the next two run no instructions at all
+	cpu := &fakeCPU{cycles: 300}
+	s := New(cpu, 6000, 60)
+	for i, want := range []int{300, 300, 300, 600, 600, 600} {
+		if err := s.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+		if cpu.total != want {
+			t.Errorf("frame %d: %d cycles run, want %d", i, cpu.total, want)
+		}
+		if cpu.total-int(s.Frame)*100 != s.position {
+			t.Errorf("frame %d: position %d, want %d", i, s.position, cpu.total-int(s.Frame)*100)
+		}
+	}
+}
+
+func TestRunFrameError(t *testing.T) {
+	errStop := errors.New("stop")
+	cpu := &fakeCPU{cycles: 10, err: errStop, errAt: 40}
+	s := New(cpu, 6000, 60)
+	fired := false
+	s.At(50, func() { fired = true })
+	if err := s.RunFrame(); !errors.Is(err, errStop) {
+		t.Fatalf("RunFrame: %v, want %v", err, errStop)
+	}
+	if fired || s.Frame != 0 || s.position != 40 {
+		t.Errorf("after error: fired %v, Frame %d, position %d, want false, 0, 40", fired, s.Frame, s.position)
+	}
+}
+
+func TestSync(t *testing.T) {
+	s := New(&fakeCPU{cycles: 4}, 6000, 60)
+
+	s.Throttle = false
+	start := time.Now()
+	for i := 0; i < 600; i++ {
+		s.Sync()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("600 unthrottled frames took %v", elapsed)
+	}
+
+	s.Throttle = true
+	start = time.Now()
+	for i := 0; i < 6; i++ {
+		s.Sync()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("6 frames at 60 Hz took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestSyncAfterManyFrames(t *testing.T) {
+	// a million frames at 2 MHz is far enough into a run that multiplying
+	// frames by cycles by nanoseconds overflows an int64
+	s := New(&fakeCPU{cycles: 4}, DefaultClockHz, 60)
+	const frames = 1000000
+	start := time.Now().Add(-time.Duration(frames) * s.frameDuration)
+	s.start, s.synced = start, frames-1
+	s.Sync()
+	if s.synced != frames || !s.start.Equal(start) {
+		t.Errorf("after %d frames Sync restarted pacing: synced %d", frames, s.synced)
+	}
+}
@@ -0,0 +1,58 @@
+package terminal
+
+import "io"
+
+// Key is a key read from the terminal. Printable keys and control characters
+// are their own rune values; the arrow keys have the negative values below.
+type Key rune
+
+const (
+	KeyUp Key = -(iota + 1)
+	KeyDown
+	KeyRight
+	KeyLeft
+)
+
+// KeyCtrlC is the byte a raw-mode terminal sends for Ctrl-C.
+const KeyCtrlC Key = 0x03
+
+// arrowKeys maps the final byte of an ESC [ x arrow key sequence.
+var arrowKeys = map[byte]Key{'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft}
+
+// ReadKeys reads keys from r until it fails and sends them on the returned
+// channel, which is closed when reading stops. r is usually os.Stdin in raw
+// mode, where terminals only report presses, never releases.
+func ReadKeys(r io.Reader) <-chan Key {
+	keys := make(chan Key, 16)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Read(buf)
+			for _, key := range decodeKeys(buf[:n]) {
+				keys <- key
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return keys
+}
+
+// decodeKeys splits one read from the terminal into keys. Escape sequences
+// other than the arrow keys are dropped.
+func decodeKeys(data []byte) []Key {
+	var keys []Key
+	for i := 0; i < len(data); i++ {
+		if data[i] == 0x1b && i+2 < len(data) && data[i+1] == '[' {
+			if key, ok := arrowKeys[data[i+2]]; ok {
+				keys = append(keys, key)
+			}
+			i += 2
+			continue
+		}
+		keys = append(keys, Key(data[i]))
+	}
+	return keys
+}
@@ -0,0 +1,44 @@
+//go:build linux
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func ioctlTermios(fd uintptr, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// MakeRaw puts the terminal on f into raw mode, so that key presses are
+// delivered immediately and not echoed, and returns a function that restores
+// the previous mode.
+func MakeRaw(f *os.File) (restore func() error, err error) {
+	var old syscall.Termios
+	if err := ioctlTermios(f.Fd(), syscall.TCGETS, &old); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctlTermios(f.Fd(), syscall.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return ioctlTermios(f.Fd(), syscall.TCSETS, &old)
+	}, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+// MakeRaw is only implemented on Linux.
+func MakeRaw(f *os.File) (restore func() error, err error) {
+	return nil, errors.New("terminal: raw mode is not supported on this platform")
+}
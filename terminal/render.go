@@ -0,0 +1,177 @@
+// Package terminal draws emulated screens on an ANSI terminal and reads
+// keyboard input from it.
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Mode selects how pixels are packed into character cells.
+type Mode int
+
+const (
+	// HalfBlock draws two pixels per cell, one above the other, using the
+	// Unicode half-block characters.
+	HalfBlock Mode = iota
+	// Braille draws a 2x4 block of pixels per cell using the Unicode braille
+	// patterns. It is a quarter of the size of HalfBlock.
+	Braille
+)
+
+const (
+	escHome       = "\x1b[H"
+	escClear      = "\x1b[2J"
+	escHideCursor = "\x1b[?25l"
+	escShowCursor = "\x1b[?25h"
+	escReset      = "\x1b[0m"
+)
+
+// Renderer redraws a screen in place on a terminal.
+type Renderer struct {
+	Mode Mode
+
+	out     io.Writer
+	buf     bytes.Buffer
+	started bool
+}
+
+// NewRenderer returns a renderer that writes to out.
+func NewRenderer(out io.Writer, mode Mode) *Renderer {
+	return &Renderer{Mode: mode, out: out}
+}
+
+// Draw renders img at the top left of the terminal in the colours of its
+// palette. Pixels with palette index 0 are unlit and left in the terminal's
+// background colour.
+func (r *Renderer) Draw(img *image.Paletted) error {
+	r.buf.Reset()
+	if !r.started {
+		r.buf.WriteString(escClear + escHideCursor)
+		r.started = true
+	}
+	r.buf.WriteString(escHome)
+	switch r.Mode {
+	case Braille:
+		drawBraille(&r.buf, img)
+	default:
+		drawHalfBlock(&r.buf, img)
+	}
+	_, err := r.out.Write(r.buf.Bytes())
+	return err
+}
+
+// Close restores the cursor and colours.
+func (r *Renderer) Close() error {
+	_, err := io.WriteString(r.out, escReset+escShowCursor+"\r\n")
+	return err
+}
+
+// index returns the palette index of the pixel at x, y, or 0 outside img.
+func index(img *image.Paletted, x, y int) uint8 {
+	if !(image.Point{x, y}.In(img.Rect)) {
+		return 0
+	}
+	return img.ColorIndexAt(x, y)
+}
+
+// pen emits 24-bit colour escapes for palette entries, skipping those that
+// would not change the current colours. Index 0 selects the terminal's
+// default colour, so unlit pixels show the terminal background.
+type pen struct {
+	buf     *bytes.Buffer
+	palette color.Palette
+	fg, bg  int
+}
+
+func newPen(buf *bytes.Buffer, palette color.Palette) *pen {
+	return &pen{buf: buf, palette: palette, fg: -1, bg: -1}
+}
+
+func (p *pen) set(fg, bg uint8) {
+	if int(fg) == p.fg && int(bg) == p.bg {
+		return
+	}
+	p.buf.WriteString(escReset)
+	if fg != 0 {
+		r, g, b, _ := p.palette[fg].RGBA()
+		fmt.Fprintf(p.buf, "\x1b[38;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+	}
+	if bg != 0 {
+		r, g, b, _ := p.palette[bg].RGBA()
+		fmt.Fprintf(p.buf, "\x1b[48;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+	}
+	p.fg, p.bg = int(fg), int(bg)
+}
+
+// endLine resets the colours so they do not bleed to the end of the row.
+func (p *pen) endLine() {
+	p.set(0, 0)
+	p.buf.WriteString("\r\n")
+}
+
+func drawHalfBlock(buf *bytes.Buffer, img *image.Paletted) {
+	p := newPen(buf, img.Palette)
+	b := img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top, bottom := index(img, x, y), index(img, x, y+1)
+			switch {
+			case top == 0 && bottom == 0:
+				p.set(0, 0)
+				buf.WriteRune(' ')
+			case top == bottom:
+				p.set(top, 0)
+				buf.WriteRune('█')
+			case bottom == 0:
+				p.set(top, 0)
+				buf.WriteRune('▀')
+			case top == 0:
+				p.set(bottom, 0)
+				buf.WriteRune('▄')
+			default:
+				p.set(top, bottom)
+				buf.WriteRune('▀')
+			}
+		}
+		p.endLine()
+	}
+}
+
+// brailleDots gives the dot bit for each pixel of a 2x4 cell, indexed by
+// [y][x].
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// drawBraille packs 2x4 pixels per cell. A cell has a single colour, taken
+// from its first lit pixel.
+func drawBraille(buf *bytes.Buffer, img *image.Paletted) {
+	p := newPen(buf, img.Palette)
+	b := img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y += 4 {
+		for x := b.Min.X; x < b.Max.X; x += 2 {
+			cell := rune(0x2800)
+			var fg uint8
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if i := index(img, x+dx, y+dy); i != 0 {
+						cell |= brailleDots[dy][dx]
+						if fg == 0 {
+							fg = i
+						}
+					}
+				}
+			}
+			p.set(fg, 0)
+			buf.WriteRune(cell)
+		}
+		p.endLine()
+	}
+}